@@ -0,0 +1,233 @@
+// Command es inspects and repairs the event store of a service.
+//
+//	es -config config.json [-plugin registry.so] <command> [flags]
+//
+// The registry comes from a plugin exporting
+// NewRegistry(service string) (es.Registry, error) or from a build tag
+// such as -tags users.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-apis/eventsourcing/es"
+	"github.com/go-apis/eventsourcing/es/ops"
+	"github.com/google/uuid"
+
+	_ "github.com/go-apis/eventsourcing/es/providers/data/pg"
+	_ "github.com/go-apis/eventsourcing/es/providers/data/sqlite"
+	_ "github.com/go-apis/eventsourcing/es/providers/stream/apub"
+	_ "github.com/go-apis/eventsourcing/es/providers/stream/gpub"
+	_ "github.com/go-apis/eventsourcing/es/providers/stream/mpub"
+	_ "github.com/go-apis/eventsourcing/es/providers/stream/noop"
+	_ "github.com/go-apis/eventsourcing/es/providers/stream/npub"
+)
+
+const usage = `usage: es -config config.json [-plugin registry.so] <command> [flags]
+
+commands:
+  events             list events
+  aggregate          show the stream and state of an aggregate
+  commands           list persisted commands
+  cancel             cancel a persisted command
+  deadletters        list dead lettered commands
+  redrive            retry a dead lettered command
+  event-deadletters  list messages event handlers gave up on
+  event-redrive      handle a dead lettered message again
+  processed-purge    forget handled events older than -older-than
+  replay             replay an aggregate
+  rebuild            rebuild a projector target or projected aggregate
+  dump               dump a namespace as json lines
+  restore            restore a namespace from json lines
+`
+
+func loadConfig(path string) (*es.ProviderConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pcfg := &es.ProviderConfig{}
+	if err := json.Unmarshal(raw, pcfg); err != nil {
+		return nil, err
+	}
+
+	// the cli should never reset the store, run scheduled commands or consume events.
+	pcfg.Data.Reset = false
+	pcfg.Scheduler.Disable = true
+	pcfg.Stream.Type = "noop"
+	return pcfg, nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func parseId(fs *flag.FlagSet, raw string) (uuid.UUID, error) {
+	if raw == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: invalid id: %w", fs.Name(), err)
+	}
+	return id, nil
+}
+
+func run(ctx context.Context, op *ops.Operator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command")
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	namespace := fs.String("namespace", "", "namespace")
+	name := fs.String("name", "", "aggregate or entity name")
+	rawId := fs.String("id", "", "aggregate, command or dead letter id")
+	eventType := fs.String("type", "", "event type")
+	group := fs.String("group", "", "event handler group")
+	limit := fs.Int("limit", 100, "max results")
+	offset := fs.Int("offset", 0, "skip results")
+	file := fs.String("file", "", "file to dump to or restore from, defaults to stdout/stdin")
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "age of the handled events to forget")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	id, err := parseId(fs, *rawId)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "events":
+		events, err := op.FindEvents(ctx, ops.EventQuery{
+			Namespace:     *namespace,
+			AggregateType: *name,
+			AggregateId:   id,
+			Type:          *eventType,
+			Limit:         *limit,
+			Offset:        *offset,
+		})
+		if err != nil {
+			return err
+		}
+		return printJSON(events)
+	case "aggregate":
+		if *namespace == "" {
+			*namespace = es.GetNamespace(ctx)
+		}
+		stream, err := op.LoadAggregate(ctx, *name, *namespace, id)
+		if err != nil {
+			return err
+		}
+		return printJSON(stream)
+	case "commands":
+		cmds, err := op.ListCommands(ctx, *namespace)
+		if err != nil {
+			return err
+		}
+		return printJSON(cmds)
+	case "cancel":
+		return op.CancelCommand(ctx, *namespace, id)
+	case "deadletters":
+		cmds, err := op.ListDeadLetters(ctx, *namespace)
+		if err != nil {
+			return err
+		}
+		return printJSON(cmds)
+	case "redrive":
+		return op.Redrive(ctx, *namespace, id)
+	case "event-deadletters":
+		deadLetters, err := op.ListEventDeadLetters(ctx, *group)
+		if err != nil {
+			return err
+		}
+		return printJSON(deadLetters)
+	case "event-redrive":
+		return op.RedriveEvent(ctx, id)
+	case "processed-purge":
+		total, err := op.PurgeProcessedEvents(ctx, time.Now().Add(-*olderThan))
+		if err != nil {
+			return err
+		}
+		log.Printf("forgot %d handled events", total)
+		return nil
+	case "replay":
+		if *namespace == "" {
+			*namespace = es.GetNamespace(ctx)
+		}
+		return op.Replay(ctx, *name, *namespace, id)
+	case "rebuild":
+		total, err := op.RebuildProjection(ctx, *name)
+		if err != nil {
+			return err
+		}
+		log.Printf("rebuilt %s from %d events or aggregates", *name, total)
+		return nil
+	case "dump":
+		var w io.Writer = os.Stdout
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return op.DumpNamespace(ctx, *namespace, w)
+	case "restore":
+		var r io.Reader = os.Stdin
+		if *file != "" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		return op.RestoreNamespace(ctx, *namespace, r)
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+func main() {
+	configPath := flag.String("config", "config.json", "provider config")
+	pluginPath := flag.String("plugin", "", "plugin exporting NewRegistry")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pcfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("loading config: %v", err)
+	}
+
+	reg, err := loadRegistry(pcfg.Service, *pluginPath)
+	if err != nil {
+		log.Fatalf("loading registry: %v", err)
+	}
+
+	cli, err := es.NewClient(ctx, pcfg, reg)
+	if err != nil {
+		log.Fatalf("connecting: %v", err)
+	}
+
+	if err := run(ctx, ops.NewOperator(cli), flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
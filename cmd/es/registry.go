@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/go-apis/eventsourcing/es"
+)
+
+// RegistryFactory builds the registry of the service being operated on.
+type RegistryFactory func(service string) (es.Registry, error)
+
+// registryFactory is set by a build tagged file when not using a plugin.
+var registryFactory RegistryFactory
+
+func loadRegistry(service string, pluginPath string) (es.Registry, error) {
+	if pluginPath == "" {
+		if registryFactory == nil {
+			return nil, fmt.Errorf("no registry, use -plugin or build with a registry tag")
+		}
+		return registryFactory(service)
+	}
+
+	p, err := plugin.Open(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("NewRegistry")
+	if err != nil {
+		return nil, err
+	}
+
+	switch fn := sym.(type) {
+	case func(string) (es.Registry, error):
+		return fn(service)
+	case *RegistryFactory:
+		return (*fn)(service)
+	default:
+		return nil, fmt.Errorf("plugin NewRegistry has the wrong signature: %T", sym)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build users
+
+package main
+
+import "github.com/go-apis/eventsourcing/examples/users/data"
+
+func init() {
+	registryFactory = data.NewRegistry
+}
@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-apis/eventsourcing/es"
+	"github.com/go-apis/eventsourcing/es/ops"
+	"github.com/google/uuid"
+)
+
+//go:embed ui
+var ui embed.FS
+
+type Option func(*admin)
+
+// WithWriteActions allows replaying aggregates, rebuilding projections
+// and cancelling persisted commands.
+func WithWriteActions() Option {
+	return func(a *admin) {
+		a.write = true
+	}
+}
+
+type admin struct {
+	op    *ops.Operator
+	write bool
+}
+
+type config struct {
+	Write       bool     `json:"write"`
+	Projections []string `json:"projections"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+func queryInt(r *http.Request, key string, fallback int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func (a *admin) writes(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.write {
+			writeError(w, http.StatusForbidden, errors.New("write actions are disabled"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (a *admin) getConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &config{
+		Write:       a.write,
+		Projections: a.op.Projections(),
+	})
+}
+
+func (a *admin) namespaces(w http.ResponseWriter, r *http.Request) {
+	namespaces, err := a.op.Namespaces(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, namespaces)
+}
+
+func (a *admin) aggregateTypes(w http.ResponseWriter, r *http.Request) {
+	types, err := a.op.AggregateTypes(r.Context(), r.PathValue("namespace"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, types)
+}
+
+func (a *admin) events(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var id uuid.UUID
+	if raw := q.Get("aggregate_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		id = parsed
+	}
+
+	events, err := a.op.FindEvents(r.Context(), ops.EventQuery{
+		Namespace:     q.Get("namespace"),
+		AggregateType: q.Get("aggregate_type"),
+		AggregateId:   id,
+		Type:          q.Get("type"),
+		Limit:         queryInt(r, "limit", 100),
+		Offset:        queryInt(r, "offset", 0),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (a *admin) aggregate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := a.op.LoadAggregate(r.Context(), r.PathValue("name"), r.PathValue("namespace"), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stream)
+}
+
+func (a *admin) commands(w http.ResponseWriter, r *http.Request) {
+	cmds, err := a.op.ListCommands(r.Context(), r.URL.Query().Get("namespace"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cmds)
+}
+
+func (a *admin) replay(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.op.Replay(r.Context(), r.PathValue("name"), r.PathValue("namespace"), id); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *admin) cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.op.CancelCommand(r.Context(), r.PathValue("namespace"), id); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *admin) rebuild(w http.ResponseWriter, r *http.Request) {
+	// only projector targets are rebuilt from the console.
+	name := r.PathValue("name")
+	if !a.op.IsProjection(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s: %w", name, ops.ErrNotProjection))
+		return
+	}
+
+	total, err := a.op.RebuildProjection(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"events": total})
+}
+
+// NewHandler returns the admin console, mount it with http.StripPrefix.
+// It is read-only unless WithWriteActions is passed.
+func NewHandler(cli es.Client, opts ...Option) http.Handler {
+	a := &admin{
+		op: ops.NewOperator(cli),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, ui, "ui/index.html")
+	})
+	mux.HandleFunc("GET /api/config", a.getConfig)
+	mux.HandleFunc("GET /api/namespaces", a.namespaces)
+	mux.HandleFunc("GET /api/namespaces/{namespace}/types", a.aggregateTypes)
+	mux.HandleFunc("GET /api/events", a.events)
+	mux.HandleFunc("GET /api/aggregates/{name}/{namespace}/{id}", a.aggregate)
+	mux.HandleFunc("GET /api/commands", a.commands)
+	mux.HandleFunc("POST /api/aggregates/{name}/{namespace}/{id}/replay", a.writes(a.replay))
+	mux.HandleFunc("POST /api/commands/{namespace}/{id}/cancel", a.writes(a.cancel))
+	mux.HandleFunc("POST /api/projections/{name}/rebuild", a.writes(a.rebuild))
+	return mux
+}
@@ -18,6 +18,18 @@ type SetVersion interface {
 	SetVersion(version int)
 }
 
+// VersionedEntity is only saved while its version matches the stored one.
+type VersionedEntity interface {
+	Entity
+	SetVersion
+
+	GetVersion() int
+}
+
+type ClearEvents interface {
+	ClearEvents()
+}
+
 type BaseAggregate struct {
 	Id        uuid.UUID `json:"id" format:"uuid" required:"true"`
 	Namespace string    `json:"namespace" required:"true"`
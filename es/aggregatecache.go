@@ -0,0 +1,189 @@
+package es
+
+import (
+	"container/list"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type cacheEntry struct {
+	key     string
+	raw     json.RawMessage
+	expires time.Time
+}
+
+type lru struct {
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List
+}
+
+func (l *lru) get(key string) (*cacheEntry, bool) {
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		l.order.Remove(el)
+		delete(l.items, key)
+		return nil, false
+	}
+
+	l.order.MoveToFront(el)
+	return entry, true
+}
+
+func (l *lru) set(entry *cacheEntry) {
+	if l.ttl > 0 {
+		entry.expires = time.Now().Add(l.ttl)
+	}
+
+	if el, ok := l.items[entry.key]; ok {
+		el.Value = entry
+		l.order.MoveToFront(el)
+		return
+	}
+
+	l.items[entry.key] = l.order.PushFront(entry)
+	for l.order.Len() > l.size {
+		last := l.order.Back()
+		l.order.Remove(last)
+		delete(l.items, last.Value.(*cacheEntry).key)
+	}
+}
+
+func (l *lru) remove(key string) {
+	if el, ok := l.items[key]; ok {
+		l.order.Remove(el)
+		delete(l.items, key)
+	}
+}
+
+// AggregateCache keeps recently loaded sourced aggregates in memory so only
+// events newer than the cached version have to be read. Entities opt in with
+// EntityCache.
+type AggregateCache struct {
+	mu       sync.Mutex
+	entities map[string]*lru
+}
+
+func (c *AggregateCache) getLru(entityConfig *EntityConfig) *lru {
+	name := strings.ToLower(entityConfig.Name)
+	if l, ok := c.entities[name]; ok {
+		return l
+	}
+
+	l := &lru{
+		size:  entityConfig.CacheSize,
+		ttl:   entityConfig.CacheTTL,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+	c.entities[name] = l
+	return l
+}
+
+func cacheKey(namespace string, id uuid.UUID) string {
+	return namespace + "__" + id.String()
+}
+
+func newCacheEntry(aggregate AggregateSourced) (*cacheEntry, error) {
+	raw, err := json.Marshal(aggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cacheEntry{
+		key: cacheKey(aggregate.GetNamespace(), aggregate.GetId()),
+		raw: raw,
+	}, nil
+}
+
+// Get fills out with the cached aggregate and reports whether it was found.
+func (c *AggregateCache) Get(entityConfig *EntityConfig, namespace string, id uuid.UUID, out AggregateSourced) bool {
+	if c == nil || entityConfig.CacheSize <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.getLru(entityConfig).get(cacheKey(namespace, id))
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(entry.raw, out); err != nil {
+		c.Remove(entityConfig, namespace, id)
+		return false
+	}
+	return true
+}
+
+// Set stores a copy of the aggregate.
+func (c *AggregateCache) Set(entityConfig *EntityConfig, aggregate AggregateSourced) error {
+	if c == nil || entityConfig.CacheSize <= 0 {
+		return nil
+	}
+
+	entry, err := newCacheEntry(aggregate)
+	if err != nil {
+		return err
+	}
+	c.set(entityConfig, entry)
+	return nil
+}
+
+func (c *AggregateCache) set(entityConfig *EntityConfig, entry *cacheEntry) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.getLru(entityConfig).set(entry)
+}
+
+// Remove invalidates the cached aggregate.
+func (c *AggregateCache) Remove(entityConfig *EntityConfig, namespace string, id uuid.UUID) {
+	if c == nil || entityConfig.CacheSize <= 0 {
+		return
+	}
+	c.remove(entityConfig, cacheKey(namespace, id))
+}
+
+func (c *AggregateCache) remove(entityConfig *EntityConfig, key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.getLru(entityConfig).remove(key)
+}
+
+// Clear drops every cached aggregate of the entity.
+func (c *AggregateCache) Clear(entityConfig *EntityConfig) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entities, strings.ToLower(entityConfig.Name))
+}
+
+func NewAggregateCache() *AggregateCache {
+	return &AggregateCache{
+		entities: make(map[string]*lru),
+	}
+}
@@ -0,0 +1,66 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type cachedAggregate struct {
+	BaseAggregateSourced
+
+	Name string
+}
+
+func Test_AggregateCache(t *testing.T) {
+	cfg := &EntityConfig{Name: "cachedAggregate", CacheSize: 2}
+	cache := NewAggregateCache()
+
+	newAggregate := func(name string) *cachedAggregate {
+		agg := &cachedAggregate{Name: name}
+		agg.SetId(uuid.New(), "default")
+		agg.Version = 3
+		return agg
+	}
+
+	a := newAggregate("a")
+	b := newAggregate("b")
+	c := newAggregate("c")
+	for _, agg := range []*cachedAggregate{a, b, c} {
+		if err := cache.Set(cfg, agg); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if cache.Get(cfg, "default", a.Id, &cachedAggregate{}) {
+		t.Errorf("expected a to be evicted")
+	}
+
+	out := &cachedAggregate{}
+	if !cache.Get(cfg, "default", c.Id, out) {
+		t.Fatalf("expected c to be cached")
+	}
+	if out.Name != "c" || out.GetVersion() != 3 {
+		t.Errorf("unexpected aggregate: %+v", out)
+	}
+
+	cache.Remove(cfg, "default", c.Id)
+	if cache.Get(cfg, "default", c.Id, &cachedAggregate{}) {
+		t.Errorf("expected c to be removed")
+	}
+
+	cache.Clear(cfg)
+	if cache.Get(cfg, "default", b.Id, &cachedAggregate{}) {
+		t.Errorf("expected b to be cleared")
+	}
+
+	expiring := &EntityConfig{Name: "expiring", CacheSize: 2, CacheTTL: time.Nanosecond}
+	if err := cache.Set(expiring, b); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if cache.Get(expiring, "default", b.Id, &cachedAggregate{}) {
+		t.Errorf("expected b to be expired")
+	}
+}
@@ -9,8 +9,9 @@ import (
 )
 
 type aggregateHandler struct {
-	cfg     *EntityConfig
-	handles CommandHandles
+	cfg      *EntityConfig
+	handles  CommandHandles
+	policies Policies
 }
 
 func (b *aggregateHandler) inner(ctx context.Context, entity Entity, cmd Command) error {
@@ -41,12 +42,16 @@ func (b *aggregateHandler) HandleCommand(ctx context.Context, cmd Command) error
 		attribute.Bool("replay", replay),
 	)
 
-	agg, err := unit.Load(pctx, b.cfg.Name, aggregateId)
+	// replays rebuild from the events, never from a cached or snapshotted copy.
+	agg, err := unit.Load(pctx, b.cfg.Name, aggregateId, DataLoadForce(replay))
 	if err != nil {
 		return err
 	}
 
 	if !replay {
+		if err := b.policies.Authorize(pctx, cmd, agg); err != nil {
+			return err
+		}
 		if err := b.inner(pctx, agg, cmd); err != nil {
 			return err
 		}
@@ -58,9 +63,10 @@ func (b *aggregateHandler) HandleCommand(ctx context.Context, cmd Command) error
 	return nil
 }
 
-func NewAggregateHandler(cfg *EntityConfig, handles CommandHandles) CommandHandler {
+func NewAggregateHandler(cfg *EntityConfig, handles CommandHandles, policies Policies) CommandHandler {
 	return &aggregateHandler{
-		cfg:     cfg,
-		handles: handles,
+		cfg:      cfg,
+		handles:  handles,
+		policies: policies,
 	}
 }
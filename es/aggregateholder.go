@@ -25,3 +25,8 @@ func (a *BaseAggregateHolder) Publish(e interface{}) {
 func (a *BaseAggregateHolder) GetEvents() []interface{} {
 	return a.events
 }
+
+// ClearEvents once they have been saved.
+func (a *BaseAggregateHolder) ClearEvents() {
+	a.events = nil
+}
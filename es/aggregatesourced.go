@@ -23,6 +23,11 @@ func (a *BaseAggregateSourced) GetEvents() []interface{} {
 	return a.events
 }
 
+// ClearEvents once they have been saved.
+func (a *BaseAggregateSourced) ClearEvents() {
+	a.events = nil
+}
+
 func (a *BaseAggregateSourced) GetVersion() int {
 	return a.Version
 }
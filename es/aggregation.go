@@ -0,0 +1,170 @@
+package es
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type AggregateFunc string
+
+const (
+	AggregateCount AggregateFunc = `count`
+	AggregateSum   AggregateFunc = `sum`
+	AggregateAvg   AggregateFunc = `avg`
+	AggregateMin   AggregateFunc = `min`
+	AggregateMax   AggregateFunc = `max`
+)
+
+type DateBucket string
+
+const (
+	BucketHour  DateBucket = `hour`
+	BucketDay   DateBucket = `day`
+	BucketWeek  DateBucket = `week`
+	BucketMonth DateBucket = `month`
+	BucketYear  DateBucket = `year`
+)
+
+// GroupBy a column, optionally truncating it into a date bucket.
+type GroupBy struct {
+	Column string
+	Bucket DateBucket
+	Alias  string
+}
+
+// Name of the column in the resulting rows.
+func (g GroupBy) Name() string {
+	if g.Alias != "" {
+		return g.Alias
+	}
+	return g.Column
+}
+
+// Measure is an aggregate function applied over a column.
+type Measure struct {
+	Func   AggregateFunc
+	Column string
+	Alias  string
+}
+
+// Name of the column in the resulting rows.
+func (m Measure) Name() string {
+	if m.Alias != "" {
+		return m.Alias
+	}
+	if m.Column == "" || m.Column == "*" {
+		return string(m.Func)
+	}
+	return fmt.Sprintf("%s_%s", m.Func, m.Column)
+}
+
+func Count(alias string) Measure {
+	return Measure{Func: AggregateCount, Alias: alias}
+}
+func Sum(column string, alias string) Measure {
+	return Measure{Func: AggregateSum, Column: column, Alias: alias}
+}
+func Avg(column string, alias string) Measure {
+	return Measure{Func: AggregateAvg, Column: column, Alias: alias}
+}
+func Min(column string, alias string) Measure {
+	return Measure{Func: AggregateMin, Column: column, Alias: alias}
+}
+func Max(column string, alias string) Measure {
+	return Measure{Func: AggregateMax, Column: column, Alias: alias}
+}
+
+// AggregateRow is a single row of an aggregation keyed by the group and measure names.
+// The getters smooth over the different types each dialect returns.
+type AggregateRow map[string]interface{}
+
+func (r AggregateRow) Int64(name string) int64 {
+	switch v := r[name].(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case []byte:
+		i, _ := strconv.ParseFloat(string(v), 64)
+		return int64(i)
+	case string:
+		i, _ := strconv.ParseFloat(v, 64)
+		return int64(i)
+	default:
+		return 0
+	}
+}
+
+func (r AggregateRow) Float64(name string) float64 {
+	switch v := r[name].(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	case []byte:
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+func (r AggregateRow) String(name string) string {
+	switch v := r[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+var bucketLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func (r AggregateRow) Time(name string) time.Time {
+	switch v := r[name].(type) {
+	case time.Time:
+		return v
+	case []byte:
+		return parseBucket(string(v))
+	case string:
+		return parseBucket(v)
+	default:
+		return time.Time{}
+	}
+}
+
+func parseBucket(v string) time.Time {
+	for _, layout := range bucketLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
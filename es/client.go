@@ -2,6 +2,7 @@ package es
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,7 +21,9 @@ func GenerateName(group string) string {
 }
 
 type Client interface {
+	Registry() Registry
 	Unit(ctx context.Context) (Unit, error)
+	Scheduler() Scheduler
 }
 
 type client struct {
@@ -28,6 +31,15 @@ type client struct {
 	registry       Registry
 	conn           Conn
 	publisher      EventPublisher
+	cache          *AggregateCache
+}
+
+func (c *client) Registry() Registry {
+	return c.registry
+}
+
+func (c *client) Scheduler() Scheduler {
+	return &clientScheduler{client: c}
 }
 
 func (c *client) Unit(ctx context.Context) (Unit, error) {
@@ -37,13 +49,43 @@ func (c *client) Unit(ctx context.Context) (Unit, error) {
 	}
 
 	// create it.
-	unit, err := newUnit(ctx, c.providerConfig.Service, c.registry, c.conn, c.publisher)
+	unit, err := newUnit(ctx, c.providerConfig.Service, c.registry, c.conn, c.publisher, c.cache)
 	if err != nil {
 		return nil, err
 	}
 	return unit, nil
 }
 
+// retry runs the handler under the policy and dead letters the message
+// once it gives up, so the broker stops redelivering it.
+func (c *client) retry(group string, policy *RetryPolicy, handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, payload []byte) error {
+		attempts, err := policy.Run(ctx, func(ctx context.Context) error {
+			return handler(ctx, payload)
+		})
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		unit, uerr := c.Unit(ctx)
+		if uerr != nil {
+			return err
+		}
+		deadLetter := &DeadLetter{
+			Id:        uuid.New(),
+			Group:     group,
+			Payload:   payload,
+			Error:     err.Error(),
+			Attempts:  attempts,
+			CreatedAt: time.Now(),
+		}
+		if derr := unit.Data().SaveDeadLetter(ctx, deadLetter); derr != nil {
+			return err
+		}
+		return nil
+	}
+}
+
 func NewClient(ctx context.Context, pcfg *ProviderConfig, reg Registry) (cli Client, err error) {
 	conn, err := GetConn(ctx, pcfg, reg)
 	if err != nil {
@@ -54,6 +96,7 @@ func NewClient(ctx context.Context, pcfg *ProviderConfig, reg Registry) (cli Cli
 		providerConfig: pcfg,
 		registry:       reg,
 		conn:           conn,
+		cache:          NewAggregateCache(),
 	}
 
 	scheduler, err := NewCommandScheduler(ctx, client)
@@ -72,7 +115,7 @@ func NewClient(ctx context.Context, pcfg *ProviderConfig, reg Registry) (cli Cli
 		}
 
 		name := GenerateName(group)
-		handler := MessageHandler(func(ctx context.Context, payload []byte) error {
+		var handler MessageHandler = func(ctx context.Context, payload []byte) error {
 			evt, err := reg.ParseEvent(ctx, payload)
 			if err != nil {
 				return err
@@ -88,8 +131,12 @@ func NewClient(ctx context.Context, pcfg *ProviderConfig, reg Registry) (cli Cli
 			if err != nil {
 				return err
 			}
-			return unit.Handle(innerCtx, group, evt)
-		})
+			// brokers deliver at least once, skip events this group already handled.
+			return unit.Handle(SetDeduplicate(innerCtx), group, evt)
+		}
+		if policy := reg.GetRetryPolicy(group); policy != nil {
+			handler = client.retry(group, policy, handler)
+		}
 		if err := streamer.AddHandler(ctx, name, handler); err != nil {
 			return nil, err
 		}
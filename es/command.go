@@ -14,6 +14,14 @@ type Command interface {
 	GetAggregateId() uuid.UUID
 }
 
+// DispatchResult describes what a dispatched command produced.
+type DispatchResult struct {
+	AggregateId uuid.UUID `json:"aggregate_id"`
+	Version     int       `json:"version"`
+	Events      []*Event  `json:"events,omitempty"`
+	ScheduledId uuid.UUID `json:"scheduled_id"`
+}
+
 type ScheduledCommand interface {
 	Command
 
@@ -31,6 +39,8 @@ type NamespaceCommand interface {
 	GetNamespace() string
 }
 
+// BaseNamespaceCommand overrides the namespace from the context, embed it
+// with required:"false" to fall back to the context when it is empty.
 type BaseNamespaceCommand struct {
 	Namespace string `json:"namespace" required:"true"`
 }
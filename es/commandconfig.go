@@ -13,6 +13,10 @@ type CommandConfig struct {
 	Name    string
 	Type    reflect.Type
 	Factory CommandFactory
+
+	// Internal commands are dispatched by the runtime only,
+	// they're not exposed by the gateway or schemas.
+	Internal bool
 }
 
 func NewCommandConfig(obj interface{}) *CommandConfig {
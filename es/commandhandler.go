@@ -17,6 +17,15 @@ func (h CommandHandlerFunc) Handle(ctx context.Context, cmd Command) error {
 	return h(ctx, cmd)
 }
 
+// HandleCommand implements CommandHandler.
+func (h CommandHandlerFunc) HandleCommand(ctx context.Context, cmd Command) error {
+	return h(ctx, cmd)
+}
+
+// CommandMiddleware wraps the handling of every command. The context carries
+// the namespace and Actor of the command.
+type CommandMiddleware func(next CommandHandler) CommandHandler
+
 type IsCommandHandler interface {
 	IsCommandHandler()
 }
@@ -27,21 +36,26 @@ type BaseCommandHandler struct {
 func (BaseCommandHandler) IsCommandHandler() {}
 
 type commandHandler struct {
-	h       IsCommandHandler
-	handles CommandHandles
+	h        IsCommandHandler
+	handles  CommandHandles
+	policies Policies
 }
 
 func (h *commandHandler) HandleCommand(ctx context.Context, cmd Command) error {
 	if h.handles == nil {
 		return fmt.Errorf("no handler for command: %T", cmd)
 	}
+	if err := h.policies.Authorize(ctx, cmd, nil); err != nil {
+		return err
+	}
 
 	return h.handles.Handle(h.h, ctx, cmd)
 }
 
-func NewCommandHandler(h IsCommandHandler, handles CommandHandles) CommandHandler {
+func NewCommandHandler(h IsCommandHandler, handles CommandHandles, policies Policies) CommandHandler {
 	return &commandHandler{
-		h:       h,
-		handles: handles,
+		h:        h,
+		handles:  handles,
+		policies: policies,
 	}
 }
@@ -2,11 +2,21 @@ package es
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
+const defaultSchedulerInterval = 30 * time.Second
+
+const maxListenBackoff = 30 * time.Second
+
+// ScheduledCommandNotifier ticks when the next persisted command is due,
+// or at the interval when nothing earlier is known.
 type ScheduledCommandNotifier struct {
 	C       <-chan time.Time // The channel on which the ticks are delivered.
+	mu      sync.Mutex
+	pending time.Time
+	wake    chan struct{}
 	stopper func()
 }
 
@@ -14,28 +24,115 @@ func (n *ScheduledCommandNotifier) Stop() {
 	n.stopper()
 }
 
-func NewScheduledCommandNotifier(ctx context.Context) (*ScheduledCommandNotifier, error) {
+// Next wakes the notifier at t if that is earlier than the current wake up.
+// It never blocks so it is safe to call from the goroutine reading C.
+func (n *ScheduledCommandNotifier) Next(t time.Time) {
+	n.mu.Lock()
+	if n.pending.IsZero() || t.Before(n.pending) {
+		n.pending = t
+	}
+	n.mu.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (n *ScheduledCommandNotifier) take() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	t := n.pending
+	n.pending = time.Time{}
+	return t
+}
+
+// listen forwards the saved commands and listens again when the connection drops.
+func (n *ScheduledCommandNotifier) listen(ctx context.Context, listener CommandListener, saved <-chan time.Time) {
+	backoff := time.Second
+	for {
+		for t := range saved {
+			n.Next(t)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			var err error
+			if saved, err = listener.ListenPersistedCommands(ctx); err == nil {
+				break
+			}
+			if backoff *= 2; backoff > maxListenBackoff {
+				backoff = maxListenBackoff
+			}
+		}
+		backoff = time.Second
+
+		// anything saved while disconnected was missed.
+		n.Next(time.Now())
+	}
+}
+
+func NewScheduledCommandNotifier(ctx context.Context, interval time.Duration, listener CommandListener) (*ScheduledCommandNotifier, error) {
+	if interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+
 	inner, cancel := context.WithCancel(ctx)
 
 	ch := make(chan time.Time)
 	notifier := &ScheduledCommandNotifier{
-		C: ch,
-		stopper: func() {
+		C:       ch,
+		wake:    make(chan struct{}, 1),
+		stopper: cancel,
+	}
+
+	if listener != nil {
+		saved, err := listener.ListenPersistedCommands(inner)
+		if err != nil {
 			cancel()
-			close(ch)
-		},
+			return nil, err
+		}
+		go notifier.listen(inner, listener, saved)
 	}
 
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
+		defer close(ch)
+
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		deadline := time.Now().Add(interval)
 
 		for {
 			select {
 			case <-inner.Done():
 				return
-			case t := <-ticker.C:
-				ch <- t
+			case <-notifier.wake:
+				t := notifier.take()
+				if t.IsZero() || !t.Before(deadline) {
+					continue
+				}
+				deadline = t
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(time.Until(t))
+			case t := <-timer.C:
+				select {
+				case ch <- t:
+				case <-inner.Done():
+					return
+				}
+				deadline = time.Now().Add(interval)
+				timer.Reset(interval)
 			}
 		}
 	}()
@@ -0,0 +1,89 @@
+package es
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_ScheduledCommandNotifier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notifier, err := NewScheduledCommandNotifier(ctx, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer notifier.Stop()
+
+	// later than the current wake up so it is ignored.
+	notifier.Next(time.Now().Add(2 * time.Hour))
+	notifier.Next(time.Now().Add(10 * time.Millisecond))
+
+	select {
+	case <-notifier.C:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the notifier to wake up early")
+	}
+}
+
+func Test_ScheduledCommandNotifier_NextDoesNotBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notifier, err := NewScheduledCommandNotifier(ctx, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer notifier.Stop()
+
+	// nothing reads C while the scheduler is busy handling a tick.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			notifier.Next(time.Now())
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected next not to block")
+	}
+}
+
+type droppingListener struct {
+	calls chan struct{}
+}
+
+func (l *droppingListener) ListenPersistedCommands(ctx context.Context) (<-chan time.Time, error) {
+	l.calls <- struct{}{}
+	out := make(chan time.Time)
+	if len(l.calls) == 1 {
+		// the first connection drops straight away.
+		close(out)
+	}
+	return out, nil
+}
+
+func Test_ScheduledCommandNotifier_Reconnects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener := &droppingListener{calls: make(chan struct{}, 2)}
+	notifier, err := NewScheduledCommandNotifier(ctx, time.Hour, listener)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer notifier.Stop()
+
+	select {
+	case <-notifier.C:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expected the notifier to wake up after reconnecting")
+	}
+	if len(listener.calls) != 2 {
+		t.Fatalf("expected 2 listens, got %d", len(listener.calls))
+	}
+}
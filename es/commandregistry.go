@@ -24,8 +24,10 @@ type CommandRegistry interface {
 	AddCommandConfig(cmdConfig *CommandConfig) error
 	SetReplayHandler(h CommandHandler, entityConfig *EntityConfig) error
 	SetCommandHandler(h CommandHandler, commandConfig *CommandConfig) error
+	AddCommandMiddleware(mws ...CommandMiddleware)
 
 	GetCommandConfig(name string) (*CommandConfig, error)
+	GetCommandConfigs() []*CommandConfig
 }
 
 type commandRegistry struct {
@@ -33,6 +35,7 @@ type commandRegistry struct {
 	hash            map[string]*CommandConfig
 	commandHandlers map[string]CommandHandler
 	replayHandlers  map[string]CommandHandler
+	middlewares     []CommandMiddleware
 }
 
 func (r *commandRegistry) AddCommandConfig(cmdConfig *CommandConfig) error {
@@ -56,7 +59,11 @@ func (r *commandRegistry) GetCommandConfig(name string) (*CommandConfig, error)
 		return cmdConfig, nil
 	}
 
-	return nil, errors.New("command not found")
+	return nil, fmt.Errorf("command %s: %w", lowered, ErrNotFound)
+}
+
+func (r *commandRegistry) GetCommandConfigs() []*CommandConfig {
+	return r.commands
 }
 
 func (r *commandRegistry) SetReplayHandler(h CommandHandler, entityConfig *EntityConfig) error {
@@ -77,6 +84,28 @@ func (r *commandRegistry) SetCommandHandler(h CommandHandler, commandConfig *Com
 	return r.AddCommandConfig(commandConfig)
 }
 
+func (r *commandRegistry) AddCommandMiddleware(mws ...CommandMiddleware) {
+	r.middlewares = append(r.middlewares, mws...)
+}
+
+func (r *commandRegistry) handler(cmd Command) (CommandHandler, error) {
+	replay, ok := cmd.(ReplayCommand)
+	if ok {
+		aggregateName := strings.ToLower(replay.GetAggregateName())
+		if handler, ok := r.replayHandlers[aggregateName]; ok {
+			return handler, nil
+		}
+		return nil, ErrHandlerNotFound
+	}
+
+	commandName := strings.ToLower(utils.GetTypeName(cmd))
+	if handler, ok := r.commandHandlers[commandName]; ok {
+		return handler, nil
+	}
+
+	return nil, ErrHandlerNotFound
+}
+
 func (r *commandRegistry) HandleCommand(ctx context.Context, cmd Command) error {
 	select {
 	case <-ctx.Done():
@@ -99,21 +128,20 @@ func (r *commandRegistry) HandleCommand(ctx context.Context, cmd Command) error
 		}
 	}
 
-	replay, ok := cmd.(ReplayCommand)
-	if ok {
-		aggregateName := strings.ToLower(replay.GetAggregateName())
-		if handler, ok := r.replayHandlers[aggregateName]; ok {
-			return handler.HandleCommand(pctx, cmd)
-		}
-		return ErrHandlerNotFound
+	if err := ValidateCommand(pctx, cmd); err != nil {
+		return err
 	}
 
-	commandName := strings.ToLower(utils.GetTypeName(cmd))
-	if handler, ok := r.commandHandlers[commandName]; ok {
-		return handler.HandleCommand(pctx, cmd)
+	handler, err := r.handler(cmd)
+	if err != nil {
+		return err
 	}
 
-	return ErrHandlerNotFound
+	// the first middleware is the outermost.
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+	return handler.HandleCommand(pctx, cmd)
 }
 
 func NewCommandRegistry() CommandRegistry {
@@ -0,0 +1,51 @@
+package es
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type middlewareCommand struct {
+	BaseCommand
+}
+
+func Test_CommandMiddleware(t *testing.T) {
+	var calls []string
+	trace := func(name string) CommandMiddleware {
+		return func(next CommandHandler) CommandHandler {
+			return CommandHandlerFunc(func(ctx context.Context, cmd Command) error {
+				calls = append(calls, name+":"+GetNamespace(ctx))
+				return next.HandleCommand(ctx, cmd)
+			})
+		}
+	}
+
+	reg := NewCommandRegistry()
+	reg.AddCommandMiddleware(trace("first"), trace("second"))
+
+	h := CommandHandlerFunc(func(ctx context.Context, cmd Command) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	if err := reg.SetCommandHandler(h, NewCommandConfig(&middlewareCommand{})); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx := SetNamespace(context.Background(), "tenant")
+	cmd := &middlewareCommand{BaseCommand: BaseCommand{AggregateId: uuid.New()}}
+	if err := reg.HandleCommand(ctx, cmd); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := []string{"first:tenant", "second:tenant", "handler"}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, calls)
+		}
+	}
+}
@@ -2,9 +2,29 @@ package es
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
 	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultLeaseTTL    = 5 * time.Minute
+	defaultBatchSize   = 100
+
+	// retries wait for next_attempt_at instead of execute_after.
+	dueColumn = "COALESCE(next_attempt_at, execute_after)"
+
+	// leased commands are picked up again when the lease expires.
+	wakeColumn = "COALESCE(lease_expires_at, next_attempt_at, execute_after)"
 )
 
+var defaultBackoff = ExponentialBackoff(time.Second, time.Hour)
+
 type CommandScheduler interface {
 	Errors() <-chan error
 	Close(ctx context.Context) error
@@ -15,6 +35,7 @@ type commandScheduler struct {
 	cancel context.CancelFunc
 
 	client *client
+	owner  string
 
 	errCh chan error
 }
@@ -31,64 +52,207 @@ func (c *commandScheduler) Close(ctx context.Context) error {
 	return nil
 }
 
-func (c *commandScheduler) handle(ctx context.Context, t time.Time) error {
+// handle runs the due commands and returns when the next one is due.
+func (c *commandScheduler) handle(ctx context.Context, t time.Time) (time.Time, error) {
 	unit, err := c.client.Unit(ctx)
 	if err != nil {
-		return err
+		return time.Time{}, err
 	}
 
-	lock, err := unit.Data().Lock(ctx)
-	if err != nil {
-		return err
+	cfg := c.client.providerConfig.Scheduler
+	ttl := cfg.LeaseTTL
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
 	}
-	defer lock.Unlock(ctx)
-
-	filter := Filter{
-		Where: WhereClause{
-			Column: "execute_after",
-			Op:     OpLessThan,
-			Args:   t,
-		},
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
 	}
-	persistedCommands, err := unit.Data().FindPersistedCommands(ctx, filter)
+
+	persistedCommands, err := unit.Data().LeasePersistedCommands(ctx, c.owner, t, ttl, batchSize)
 	if err != nil {
-		return err
+		return time.Time{}, err
 	}
 
 	for _, persistedCommand := range persistedCommands {
-		inner := SetActor(ctx, persistedCommand.By)
+		// the rest of the batch is up for grabs once the lease runs out.
+		if persistedCommand.LeaseExpiresAt != nil && !time.Now().Before(*persistedCommand.LeaseExpiresAt) {
+			break
+		}
 
-		if err := unit.Dispatch(inner, persistedCommand.Command); err != nil {
-			return err
+		inner, span := otel.Tracer("CommandScheduler").Start(persistedCommand.restore(ctx), "ScheduledCommand")
+
+		if _, err := unit.Dispatch(inner, persistedCommand.Command); err != nil {
+			// keep going, one failing command shouldn't block the rest.
+			err = c.fail(inner, unit, persistedCommand, err)
+			span.End()
+			if err := c.released(err); err != nil {
+				return time.Time{}, err
+			}
+			continue
 		}
-		if err := unit.Data().DeletePersistedCommand(inner, persistedCommand); err != nil {
-			return err
+		err := c.complete(inner, unit, persistedCommand)
+		span.End()
+		if err := c.released(err); err != nil {
+			return time.Time{}, err
 		}
 	}
 
+	return c.next(ctx, unit)
+}
+
+// released reports a lease that expired while the command ran, another
+// scheduler owns the command now so the batch carries on.
+func (c *commandScheduler) released(err error) error {
+	if !errors.Is(err, ErrLeaseLost) {
+		return err
+	}
+	c.report(err)
 	return nil
 }
 
+// report hands err to Errors without waiting, errors nobody reads are dropped
+// so the scheduler never stalls on them.
+func (c *commandScheduler) report(err error) {
+	select {
+	case c.errCh <- err:
+	default:
+	}
+}
+
+// complete removes the command or, when it's recurring, schedules the next run.
+func (c *commandScheduler) complete(ctx context.Context, unit Unit, persistedCommand *PersistedCommand) error {
+	if persistedCommand.Recurrence == "" {
+		return unit.Data().DeleteLeasedCommand(ctx, c.owner, persistedCommand)
+	}
+
+	r, err := ParseRecurrence(persistedCommand.Recurrence)
+	if err != nil {
+		return c.fail(ctx, unit, persistedCommand, err)
+	}
+	next := r.Next(time.Now())
+	if next.IsZero() {
+		return unit.Data().DeleteLeasedCommand(ctx, c.owner, persistedCommand)
+	}
+
+	persistedCommand.ExecuteAfter = next
+	persistedCommand.LeasedBy = ""
+	persistedCommand.LeaseExpiresAt = nil
+	persistedCommand.Attempts = 0
+	persistedCommand.LastError = ""
+	persistedCommand.NextAttemptAt = nil
+	return unit.Data().SaveLeasedCommand(ctx, c.owner, persistedCommand)
+}
+
+// retryableCommandError is false for errors that fail the same way every run.
+func retryableCommandError(err error) bool {
+	var verr *ValidationError
+	switch {
+	case errors.As(err, &verr),
+		errors.Is(err, ErrForbidden),
+		errors.Is(err, ErrNotFound):
+		return false
+	}
+	return true
+}
+
+// fail records the attempt and either schedules a retry or dead letters the command.
+func (c *commandScheduler) fail(ctx context.Context, unit Unit, persistedCommand *PersistedCommand, cause error) error {
+	cfg := c.client.providerConfig.Scheduler
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	backoff := cfg.Backoff
+	if backoff == nil {
+		backoff = defaultBackoff
+	}
+
+	now := time.Now()
+	persistedCommand.LeasedBy = ""
+	persistedCommand.LeaseExpiresAt = nil
+	persistedCommand.Attempts++
+	persistedCommand.LastError = cause.Error()
+	if persistedCommand.Attempts >= maxAttempts || !retryableCommandError(cause) {
+		persistedCommand.DeadLetteredAt = &now
+		persistedCommand.NextAttemptAt = nil
+	} else {
+		next := now.Add(backoff(persistedCommand.Attempts))
+		persistedCommand.NextAttemptAt = &next
+	}
+
+	c.report(fmt.Errorf("persisted command %s attempt %d: %w", persistedCommand.Id, persistedCommand.Attempts, cause))
+	return unit.Data().SaveLeasedCommand(ctx, c.owner, persistedCommand)
+}
+
+func (c *commandScheduler) next(ctx context.Context, unit Unit) (time.Time, error) {
+	filter := Filter{
+		Where: WhereClause{
+			Column: "dead_lettered_at",
+			Op:     OpIsNull,
+		},
+		Order: []Order{{Expression: wakeColumn}},
+		Limit: Limit(1),
+	}
+	persistedCommands, err := unit.Data().FindPersistedCommands(ctx, filter)
+	if err != nil || len(persistedCommands) == 0 {
+		return time.Time{}, err
+	}
+
+	next := persistedCommands[0]
+	if next.LeaseExpiresAt != nil {
+		return *next.LeaseExpiresAt, nil
+	}
+	if next.NextAttemptAt != nil {
+		return *next.NextAttemptAt, nil
+	}
+	return next.ExecuteAfter, nil
+}
+
 func (c *commandScheduler) run(ctx context.Context) {
-	notifier, err := NewScheduledCommandNotifier(ctx)
+	// fall back to polling when the connection can't push.
+	listener, _ := c.client.conn.(CommandListener)
+	notifier, err := NewScheduledCommandNotifier(ctx, c.client.providerConfig.Scheduler.Interval, listener)
+	if errors.Is(err, ErrListenNotSupported) {
+		notifier, err = NewScheduledCommandNotifier(ctx, c.client.providerConfig.Scheduler.Interval, nil)
+	}
 	if err != nil {
-		c.errCh <- err
+		c.report(err)
 		return
 	}
 	defer notifier.Stop()
 
+	c.tick(ctx, notifier, time.Now())
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case t := <-notifier.C:
-			if err := c.handle(ctx, t); err != nil {
-				c.errCh <- err
+		case t, ok := <-notifier.C:
+			if !ok {
+				return
 			}
+			c.tick(ctx, notifier, t)
 		}
 	}
 }
 
+func (c *commandScheduler) tick(ctx context.Context, notifier *ScheduledCommandNotifier, t time.Time) {
+	next, err := c.handle(ctx, t)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	if !next.IsZero() {
+		notifier.Next(next)
+	}
+}
+
+// schedulerOwner identifies this instance in the leases it takes.
+func schedulerOwner() string {
+	hostname, _ := os.Hostname()
+	return hostname + "-" + uuid.NewString()
+}
+
 func NewCommandScheduler(ctx context.Context, client *client) (CommandScheduler, error) {
 	cctx, cancel := context.WithCancel(ctx)
 
@@ -96,8 +260,13 @@ func NewCommandScheduler(ctx context.Context, client *client) (CommandScheduler,
 		cctx:   cctx,
 		cancel: cancel,
 		client: client,
+		owner:  schedulerOwner(),
 		errCh:  make(chan error, 100),
 	}
+	if client.providerConfig.Scheduler.Disable {
+		return c, nil
+	}
+
 	go c.run(cctx)
 	return c, nil
 }
@@ -0,0 +1,113 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type schedulerData struct {
+	Data
+
+	saved []*PersistedCommand
+}
+
+func (d *schedulerData) SaveLeasedCommand(ctx context.Context, owner string, cmd *PersistedCommand) error {
+	saved := *cmd
+	d.saved = append(d.saved, &saved)
+	return nil
+}
+
+type schedulerUnit struct {
+	Unit
+
+	data *schedulerData
+}
+
+func (u *schedulerUnit) Data() Data {
+	return u.data
+}
+
+func Test_CommandSchedulerFail(t *testing.T) {
+	errTemporary := errors.New("temporary")
+
+	tests := []struct {
+		name         string
+		maxAttempts  int
+		attempts     int
+		cause        error
+		deadLettered bool
+	}{
+		{"retries", 5, 0, errTemporary, false},
+		{"backs off", 5, 2, errTemporary, false},
+		{"runs out of attempts", 3, 2, errTemporary, true},
+		{"validation", 5, 0, &ValidationError{Errors: []FieldError{{Field: "email", Message: "is required"}}}, true},
+		{"forbidden", 5, 0, fmt.Errorf("user: %w", ErrForbidden), true},
+		{"not found", 5, 0, fmt.Errorf("user: %w", ErrNotFound), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &commandScheduler{
+				client: &client{providerConfig: &ProviderConfig{Scheduler: SchedulerConfig{
+					MaxAttempts: tt.maxAttempts,
+					Backoff:     func(attempt int) time.Duration { return time.Duration(attempt) * time.Minute },
+				}}},
+				owner: "a",
+				errCh: make(chan error, 1),
+			}
+			unit := &schedulerUnit{data: &schedulerData{}}
+			persisted := &PersistedCommand{Attempts: tt.attempts, LeasedBy: "a"}
+
+			start := time.Now()
+			if err := c.fail(context.Background(), unit, persisted, tt.cause); err != nil {
+				t.Fatal(err)
+			}
+			if len(unit.data.saved) != 1 {
+				t.Fatalf("expected the command to be saved, got %d", len(unit.data.saved))
+			}
+			saved := unit.data.saved[0]
+			if saved.Attempts != tt.attempts+1 || saved.LastError != tt.cause.Error() || saved.LeasedBy != "" {
+				t.Errorf("unexpected attempt %+v", saved)
+			}
+			if tt.deadLettered != (saved.DeadLetteredAt != nil) {
+				t.Fatalf("expected dead lettered %v, got %v", tt.deadLettered, saved.DeadLetteredAt)
+			}
+			if tt.deadLettered {
+				if saved.NextAttemptAt != nil {
+					t.Errorf("expected no retry, got %v", saved.NextAttemptAt)
+				}
+				return
+			}
+			wait := time.Duration(tt.attempts+1) * time.Minute
+			if saved.NextAttemptAt == nil || saved.NextAttemptAt.Before(start.Add(wait)) {
+				t.Errorf("expected a retry after %s, got %v", wait, saved.NextAttemptAt)
+			}
+			if len(c.errCh) != 1 {
+				t.Errorf("expected the failure to be reported")
+			}
+		})
+	}
+}
+
+func Test_CommandSchedulerReport(t *testing.T) {
+	c := &commandScheduler{errCh: make(chan error, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		c.report(errors.New("first"))
+		c.report(errors.New("nobody is reading"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected reporting to a full channel not to block")
+	}
+	if err := <-c.Errors(); err.Error() != "first" {
+		t.Errorf("expected the first error, got %v", err)
+	}
+}
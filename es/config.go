@@ -3,6 +3,7 @@ package es
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/go-apis/utils/xgorm"
@@ -49,18 +50,32 @@ type SqliteConfig struct {
 }
 
 type DataConfig struct {
-	Type   string
-	Pg     *xgorm.DbConfig
-	Sqlite *SqliteConfig
-	Reset  bool
+	Type     string
+	Pg       *xgorm.DbConfig
+	Replicas []*xgorm.DbConfig
+	Sqlite   *SqliteConfig
+	Reset    bool
+}
+
+type SchedulerConfig struct {
+	Disable     bool
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     Backoff `json:"-"`
+
+	// LeaseTTL is how long a scheduler owns the commands it picked up,
+	// BatchSize how many it picks up at once.
+	LeaseTTL  time.Duration
+	BatchSize int
 }
 
 type ProviderConfig struct {
 	Service string
 	Version string
 
-	Data   DataConfig
-	Stream StreamConfig
+	Data      DataConfig
+	Stream    StreamConfig
+	Scheduler SchedulerConfig
 }
 
 type AggregateConfig struct {
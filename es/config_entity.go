@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-apis/eventsourcing/es/utils"
 	"github.com/jinzhu/copier"
@@ -20,6 +21,8 @@ type EntityConfig struct {
 	SnapshotEnabled  bool
 	SnapshotEvery    int
 	Project          bool
+	CacheSize        int
+	CacheTTL         time.Duration
 	Handles          EventHandles
 }
 
@@ -67,6 +70,20 @@ func NewEntityOptionsFromTag(t reflect.Type) ([]EntityOption, error) {
 				options = append(options, EntityDisableProject())
 			}
 			continue
+		case "cache":
+			i, err := strconv.Atoi(split[1])
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, EntityCacheSize(i))
+			continue
+		case "cache_ttl":
+			d, err := time.ParseDuration(split[1])
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, EntityCacheTTL(d))
+			continue
 		}
 	}
 	return options, nil
@@ -136,6 +153,16 @@ func EntityDisableProject() EntityOption {
 		o.Project = false
 	}
 }
+func EntityCacheSize(size int) EntityOption {
+	return func(o *EntityConfig) {
+		o.CacheSize = size
+	}
+}
+func EntityCacheTTL(ttl time.Duration) EntityOption {
+	return func(o *EntityConfig) {
+		o.CacheTTL = ttl
+	}
+}
 func EntityName(name string) EntityOption {
 	return func(o *EntityConfig) {
 		o.Name = name
@@ -35,7 +35,7 @@ func NewEventHandlerConfig(h interface{}) *EventHandlerConfig {
 		Group: ExternalGroup,
 	}
 
-	fieldNames := []string{"BaseEventHandler", "BaseSaga", "BaseProjector"}
+	fieldNames := []string{"BaseEventHandler", "BaseSaga", "BaseProcess", "BaseProjector"}
 	for _, fieldName := range fieldNames {
 		field, ok := t.FieldByName(fieldName)
 		if !ok {
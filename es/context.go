@@ -16,6 +16,10 @@ const (
 	ActorKey
 	SkipPublishKey
 	TimeKey
+	PrimaryOnlyKey
+	MetadataKey
+	EventKey
+	DeduplicateKey
 )
 
 const defaultNamespace = "default"
@@ -38,6 +42,11 @@ func GetActor(ctx context.Context) *Actor {
 }
 func GetMetadata(ctx context.Context) map[string]interface{} {
 	m := make(map[string]interface{})
+	if metadata, ok := ctx.Value(MetadataKey).(map[string]interface{}); ok {
+		for k, v := range metadata {
+			m[k] = v
+		}
+	}
 
 	span := trace.SpanFromContext(ctx)
 	if span != nil && span.SpanContext().HasSpanID() {
@@ -48,6 +57,13 @@ func GetMetadata(ctx context.Context) map[string]interface{} {
 	}
 	return m
 }
+func GetEvent(ctx context.Context) *Event {
+	evt, ok := ctx.Value(EventKey).(*Event)
+	if ok {
+		return evt
+	}
+	return nil
+}
 func GetUnit(ctx context.Context) (Unit, error) {
 	unit, ok := ctx.Value(UnitKey).(Unit)
 	if ok {
@@ -59,6 +75,14 @@ func GetSkipPublish(ctx context.Context) bool {
 	skip, ok := ctx.Value(SkipPublishKey).(bool)
 	return ok && skip
 }
+func GetDeduplicate(ctx context.Context) bool {
+	dedupe, ok := ctx.Value(DeduplicateKey).(bool)
+	return ok && dedupe
+}
+func GetPrimaryOnly(ctx context.Context) bool {
+	primary, ok := ctx.Value(PrimaryOnlyKey).(bool)
+	return ok && primary
+}
 func GetTime(ctx context.Context) time.Time {
 	t, ok := ctx.Value(TimeKey).(time.Time)
 	if ok {
@@ -76,9 +100,30 @@ func SetUnit(ctx context.Context, unit Unit) context.Context {
 func SetActor(ctx context.Context, actor *Actor) context.Context {
 	return context.WithValue(ctx, ActorKey, actor)
 }
+func SetMetadata(ctx context.Context, metadata map[string]interface{}) context.Context {
+	m := make(map[string]interface{})
+	if existing, ok := ctx.Value(MetadataKey).(map[string]interface{}); ok {
+		for k, v := range existing {
+			m[k] = v
+		}
+	}
+	for k, v := range metadata {
+		m[k] = v
+	}
+	return context.WithValue(ctx, MetadataKey, m)
+}
+func SetEvent(ctx context.Context, evt *Event) context.Context {
+	return context.WithValue(ctx, EventKey, evt)
+}
 func SetSkipPublish(ctx context.Context) context.Context {
 	return context.WithValue(ctx, SkipPublishKey, true)
 }
+func SetDeduplicate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, DeduplicateKey, true)
+}
+func SetPrimaryOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, PrimaryOnlyKey, true)
+}
 func SetTime(ctx context.Context, t time.Time) context.Context {
 	return context.WithValue(ctx, TimeKey, t)
 }
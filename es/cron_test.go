@@ -0,0 +1,49 @@
+package es
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseRecurrence(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"@every 90m", from.Add(90 * time.Minute)},
+		{"*/15 * * * *", time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 12 1,15 * 7", time.Date(2024, time.February, 1, 12, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got := r.Next(from); !got.Equal(tt.expected) {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.expected, got)
+		}
+	}
+
+	// hours are matched in the location of the time given.
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	r, err := ParseRecurrence("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := time.Date(2024, time.February, 1, 9, 0, 0, 0, ist)
+	if got := r.Next(time.Date(2024, time.January, 31, 10, 17, 0, 0, ist)); !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	for _, expr := range []string{"", "* * *", "60 * * * *", "@every -1h", "*/0 * * * *"} {
+		if _, err := ParseRecurrence(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
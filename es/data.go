@@ -2,6 +2,8 @@ package es
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,6 +22,15 @@ type Conn interface {
 	Close(ctx context.Context) error
 }
 
+// ErrListenNotSupported is returned by connections that can't push notifications.
+var ErrListenNotSupported = errors.New("listen not supported")
+
+// CommandListener is implemented by connections that can push the execute
+// after time of every persisted command that is saved.
+type CommandListener interface {
+	ListenPersistedCommands(ctx context.Context) (<-chan time.Time, error)
+}
+
 type Tx interface {
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
@@ -36,11 +47,23 @@ type Data interface {
 	LoadSnapshot(ctx context.Context, search SnapshotSearch, out AggregateSourced) error
 	SaveSnapshot(ctx context.Context, snapshot *Snapshot) error
 
+	CreatePersistedCommand(ctx context.Context, cmd *PersistedCommand) error
 	SavePersistedCommand(ctx context.Context, cmd *PersistedCommand) error
 	DeletePersistedCommand(ctx context.Context, cmd *PersistedCommand) error
 	FindPersistedCommands(ctx context.Context, filter Filter) ([]*PersistedCommand, error)
+	LeasePersistedCommands(ctx context.Context, owner string, due time.Time, ttl time.Duration, limit int) ([]*PersistedCommand, error)
+	SaveLeasedCommand(ctx context.Context, owner string, cmd *PersistedCommand) error
+	DeleteLeasedCommand(ctx context.Context, owner string, cmd *PersistedCommand) error
+	FindDeadLetteredCommands(ctx context.Context, filter Filter) ([]*PersistedCommand, error)
+	RedrivePersistedCommand(ctx context.Context, namespace string, id uuid.UUID) error
+
+	SaveDeadLetter(ctx context.Context, deadLetter *DeadLetter) error
+	FindDeadLetters(ctx context.Context, filter Filter) ([]*DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id uuid.UUID) error
 
 	SaveEvents(ctx context.Context, events []*Event) error
+	MarkEventProcessed(ctx context.Context, group string, evt *Event) (bool, error)
+	DeleteProcessedEvents(ctx context.Context, before time.Time) (int, error)
 	SaveEntity(ctx context.Context, aggregateName string, entity Entity) error
 	DeleteEntity(ctx context.Context, aggregateName string, entity Entity) error
 	Truncate(ctx context.Context, aggregateName string) error
@@ -49,6 +72,7 @@ type Data interface {
 	One(ctx context.Context, aggregateName string, namespace string, filter Filter, out interface{}) error
 	Find(ctx context.Context, aggregateName string, namespace string, filter Filter, out interface{}) error
 	Count(ctx context.Context, aggregateName string, namespace string, filter Filter) (int, error)
+	Aggregate(ctx context.Context, aggregateName string, namespace string, filter Filter, groupBy []GroupBy, measures []Measure) ([]AggregateRow, error)
 
 	FindEvents(ctx context.Context, filter Filter) ([]*Event, error)
 }
@@ -27,12 +27,25 @@ type DataStore interface {
 	Save(ctx context.Context, name string, aggregate Entity) ([]*Event, error)
 	Delete(ctx context.Context, name string, aggregate Entity) error
 	Truncate(ctx context.Context, name string) error
+
+	// Commit is called once the surrounding transaction is committed.
+	Commit(ctx context.Context)
+	// Rollback is called whenever a transaction, or part of it, is rolled back.
+	Rollback(ctx context.Context)
+}
+
+type pendingCacheEntry struct {
+	entityConfig *EntityConfig
+	entry        *cacheEntry
 }
 
 type dataStore struct {
 	service  string
 	data     Data
 	registry Registry
+	cache    *AggregateCache
+
+	pending map[string]*pendingCacheEntry
 }
 
 func (s *dataStore) applyEvents(ctx context.Context, entityConfig *EntityConfig, aggregate AggregateSourced, events []*Event) error {
@@ -63,8 +76,11 @@ func (s *dataStore) loadSourced(ctx context.Context, entityConfig *EntityConfig,
 	namespace := GetNamespace(ctx)
 	id := aggregate.GetId()
 
+	// a cached aggregate only needs the events after its version.
+	cached := !forced && s.cache.Get(entityConfig, namespace, id, aggregate)
+
 	// load up the aggregate
-	if entityConfig.SnapshotEnabled && entityConfig.SnapshotEvery >= 0 && !forced {
+	if entityConfig.SnapshotEnabled && entityConfig.SnapshotEvery >= 0 && !forced && !cached {
 		snapshotSearch := SnapshotSearch{
 			Namespace:     namespace,
 			AggregateId:   id,
@@ -108,6 +124,13 @@ func (s *dataStore) loadSourced(ctx context.Context, entityConfig *EntityConfig,
 	if err := s.applyEvents(ctx, entityConfig, aggregate, originalEvents); err != nil {
 		return nil, err
 	}
+
+	// our own uncommitted changes are cached on commit.
+	if _, ok := s.pending[entityConfig.Name+"__"+cacheKey(namespace, id)]; !ok {
+		if err := s.cache.Set(entityConfig, aggregate); err != nil {
+			return nil, err
+		}
+	}
 	return aggregate, nil
 }
 func (s *dataStore) loadEntity(ctx context.Context, entityConfig *EntityConfig, entity Entity) (Entity, error) {
@@ -159,9 +182,23 @@ func (s *dataStore) saveSourced(ctx context.Context, entityConfig *EntityConfig,
 	}
 
 	if err := s.data.SaveEvents(ctx, events); err != nil {
+		if errors.Is(err, ErrConcurrency) {
+			s.cache.Remove(entityConfig, namespace, id)
+		}
 		return nil, err
 	}
 
+	if entityConfig.CacheSize > 0 {
+		entry, err := newCacheEntry(aggregate)
+		if err != nil {
+			return nil, err
+		}
+		s.pending[entityConfig.Name+"__"+entry.key] = &pendingCacheEntry{
+			entityConfig: entityConfig,
+			entry:        entry,
+		}
+	}
+
 	// save the snapshot!
 	diff := aggregate.GetVersion() - version
 	if diff < 0 {
@@ -232,6 +269,9 @@ func (s *dataStore) deleteEntity(ctx context.Context, entityConfig *EntityConfig
 }
 
 func (s *dataStore) Load(ctx context.Context, name string, id uuid.UUID, opts ...DataLoadOption) (Entity, error) {
+	// aggregates are always loaded from the primary.
+	ctx = SetPrimaryOnly(ctx)
+
 	entityConfig, err := s.registry.GetEntityConfig(name)
 	if err != nil {
 		return nil, err
@@ -267,14 +307,24 @@ func (s *dataStore) Save(ctx context.Context, name string, entity Entity) ([]*Ev
 		return nil, err
 	}
 
+	var events []*Event
 	switch agg := entity.(type) {
 	case AggregateSourced:
-		return s.saveSourced(ctx, entityConfig, agg)
+		events, err = s.saveSourced(ctx, entityConfig, agg)
 	case AggregateHolder:
-		return s.saveAggregateHolder(ctx, entityConfig, agg)
+		events, err = s.saveAggregateHolder(ctx, entityConfig, agg)
 	default:
-		return s.saveEntity(ctx, entityConfig, agg)
+		events, err = s.saveEntity(ctx, entityConfig, agg)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the same instance can be saved again later in the unit.
+	if c, ok := entity.(ClearEvents); ok {
+		c.ClearEvents()
+	}
+	return events, nil
 }
 func (s *dataStore) Delete(ctx context.Context, name string, entity Entity) error {
 	entityConfig, err := s.registry.GetEntityConfig(name)
@@ -294,15 +344,41 @@ func (s *dataStore) Truncate(ctx context.Context, name string) error {
 	if err != nil {
 		return err
 	}
-	return s.data.Truncate(ctx, entityConfig.Name)
+	if err := s.data.Truncate(ctx, entityConfig.Name); err != nil {
+		return err
+	}
+
+	// nothing cached of the entity survives the truncate.
+	s.cache.Clear(entityConfig)
+	for key, p := range s.pending {
+		if p.entityConfig.Name == entityConfig.Name {
+			delete(s.pending, key)
+		}
+	}
+	return nil
+}
+
+func (s *dataStore) Commit(ctx context.Context) {
+	for key, p := range s.pending {
+		s.cache.set(p.entityConfig, p.entry)
+		delete(s.pending, key)
+	}
+}
+func (s *dataStore) Rollback(ctx context.Context) {
+	for key, p := range s.pending {
+		s.cache.remove(p.entityConfig, p.entry.key)
+		delete(s.pending, key)
+	}
 }
 
 // NewDataStore for creating stores
-func NewDataStore(service string, data Data, reg Registry) DataStore {
+func NewDataStore(service string, data Data, reg Registry, cache *AggregateCache) DataStore {
 	s := &dataStore{
 		service:  service,
 		data:     data,
 		registry: reg,
+		cache:    cache,
+		pending:  make(map[string]*pendingCacheEntry),
 	}
 	return s
 }
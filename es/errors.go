@@ -3,5 +3,8 @@ package es
 import "errors"
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound    = errors.New("not found")
+	ErrConcurrency = errors.New("concurrency conflict")
+	ErrForbidden   = errors.New("forbidden")
+	ErrLeaseLost   = errors.New("lease lost")
 )
@@ -19,6 +19,30 @@ type EventHandler interface {
 	HandleEvent(ctx context.Context, evt *Event) error
 }
 
+// EventHandlerFunc is a function that can be used as an event handler.
+type EventHandlerFunc func(context.Context, *Event) error
+
+// HandleEvent implements EventHandler.
+func (h EventHandlerFunc) HandleEvent(ctx context.Context, evt *Event) error {
+	return h(ctx, evt)
+}
+
+// EventMiddleware wraps each saga, projector and event handler.
+type EventMiddleware func(next EventHandler) EventHandler
+
+// GroupEventMiddleware is an EventMiddleware that only applies to one group.
+type GroupEventMiddleware struct {
+	Group      string
+	Middleware EventMiddleware
+}
+
+func NewGroupEventMiddleware(group string, mw EventMiddleware) *GroupEventMiddleware {
+	return &GroupEventMiddleware{
+		Group:      group,
+		Middleware: mw,
+	}
+}
+
 type GroupEventHandler interface {
 	HandleGroupEvent(ctx context.Context, group string, evt *Event) error
 }
@@ -21,19 +21,45 @@ type EventRegistry interface {
 
 	AddEvent(eventConfig *EventConfig) error
 	AddGroupEventHandler(h EventHandler, group string, eventConfig *EventConfig) error
+	AddEventMiddleware(group string, mws ...EventMiddleware)
+	AddProjectionEventHandler(h EventHandler, entityName string, eventConfig *EventConfig) error
+	SetRetryPolicy(policy *RetryPolicy)
+	HandleProjectionEvent(ctx context.Context, entityName string, evt *Event) error
 
 	GetGroups() []string
+	GetProjections() []string
+	GetRetryPolicy(group string) *RetryPolicy
 	GetEventConfig(service string, eventType string) (*EventConfig, error)
+	GetEventConfigs() []*EventConfig
 	ParseEvent(ctx context.Context, msg []byte) (*Event, error)
 }
 
 type eventRegistry struct {
-	hash  map[string]*EventConfig
-	typed map[reflect.Type]*EventConfig
+	events []*EventConfig
+	hash   map[string]*EventConfig
+	typed  map[reflect.Type]*EventConfig
 
 	groupHash     map[string]bool
 	groups        []string
 	groupHandlers map[string]EventHandlers
+
+	projectionHash     map[string]bool
+	projections        []string
+	projectionHandlers map[string]EventHandlers
+
+	middlewares      []EventMiddleware
+	groupMiddlewares map[string][]EventMiddleware
+
+	retryPolicies map[string]*RetryPolicy
+}
+
+func (r *eventRegistry) SetRetryPolicy(policy *RetryPolicy) {
+	r.retryPolicies[policy.Group] = policy
+}
+
+// GetRetryPolicy for the group, nil leaves retrying to the broker.
+func (r *eventRegistry) GetRetryPolicy(group string) *RetryPolicy {
+	return r.retryPolicies[group]
 }
 
 func (r *eventRegistry) HandleGroupEvent(ctx context.Context, group string, evt *Event) error {
@@ -43,10 +69,44 @@ func (r *eventRegistry) HandleGroupEvent(ctx context.Context, group string, evt
 		return nil
 	}
 
+	// global middlewares are outermost, then the group's.
+	mws := append(append([]EventMiddleware{}, r.middlewares...), r.groupMiddlewares[group]...)
+	if len(mws) > 0 {
+		wrapped := make(EventHandlers, len(handlers))
+		for i, h := range handlers {
+			for j := len(mws) - 1; j >= 0; j-- {
+				h = mws[j](h)
+			}
+			wrapped[i] = h
+		}
+		handlers = wrapped
+	}
+
 	withNs := SetNamespace(ctx, evt.Namespace)
 	return handlers.Handle(withNs, evt)
 }
 
+// HandleProjectionEvent only runs the projector handlers of the entity, used to rebuild it.
+func (r *eventRegistry) HandleProjectionEvent(ctx context.Context, entityName string, evt *Event) error {
+	key := strings.ToLower(entityName + "__" + evt.Service + "__" + evt.Type)
+	handlers, ok := r.projectionHandlers[key]
+	if !ok {
+		return nil
+	}
+
+	withNs := SetNamespace(ctx, evt.Namespace)
+	return handlers.Handle(withNs, evt)
+}
+
+// AddEventMiddleware for a group, or for every group when group is empty.
+func (r *eventRegistry) AddEventMiddleware(group string, mws ...EventMiddleware) {
+	if group == "" {
+		r.middlewares = append(r.middlewares, mws...)
+		return
+	}
+	r.groupMiddlewares[group] = append(r.groupMiddlewares[group], mws...)
+}
+
 func (r *eventRegistry) AddEvent(eventConfig *EventConfig) error {
 	if _, ok := r.typed[eventConfig.Type]; ok {
 		// already registered.
@@ -71,11 +131,20 @@ func (r *eventRegistry) AddEvent(eventConfig *EventConfig) error {
 	}
 
 	r.typed[eventConfig.Type] = eventConfig
+	r.events = append(r.events, eventConfig)
 	return nil
 }
 func (r *eventRegistry) GetGroups() []string {
 	return r.groups
 }
+
+// GetProjections returns the entities projectors write to.
+func (r *eventRegistry) GetProjections() []string {
+	return r.projections
+}
+func (r *eventRegistry) GetEventConfigs() []*EventConfig {
+	return r.events
+}
 func (r *eventRegistry) GetEventConfig(service string, eventType string) (*EventConfig, error) {
 	name := strings.ToLower(service + "__" + eventType)
 	if evt, ok := r.hash[name]; ok {
@@ -136,6 +205,18 @@ func (r *eventRegistry) AddGroupEventHandler(h EventHandler, group string, event
 	return r.AddEvent(eventConfig)
 }
 
+func (r *eventRegistry) AddProjectionEventHandler(h EventHandler, entityName string, eventConfig *EventConfig) error {
+	key := strings.ToLower(entityName + "__" + eventConfig.Service + "__" + eventConfig.Name)
+	r.projectionHandlers[key] = append(r.projectionHandlers[key], h)
+
+	if name := strings.ToLower(entityName); !r.projectionHash[name] {
+		r.projectionHash[name] = true
+		r.projections = append(r.projections, entityName)
+	}
+
+	return r.AddEvent(eventConfig)
+}
+
 func NewEventRegistry() EventRegistry {
 	return &eventRegistry{
 		hash:          make(map[string]*EventConfig),
@@ -143,5 +224,13 @@ func NewEventRegistry() EventRegistry {
 		groupHash:     make(map[string]bool),
 		groups:        []string{},
 		groupHandlers: make(map[string]EventHandlers),
+
+		projectionHash:     make(map[string]bool),
+		projections:        []string{},
+		projectionHandlers: make(map[string]EventHandlers),
+
+		groupMiddlewares: make(map[string][]EventMiddleware),
+
+		retryPolicies: make(map[string]*RetryPolicy),
 	}
 }
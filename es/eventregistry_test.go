@@ -0,0 +1,56 @@
+package es
+
+import (
+	"context"
+	"testing"
+)
+
+type middlewareEvent struct {
+	BaseEvent
+}
+
+func Test_EventMiddleware(t *testing.T) {
+	var calls []string
+	trace := func(name string) EventMiddleware {
+		return func(next EventHandler) EventHandler {
+			return EventHandlerFunc(func(ctx context.Context, evt *Event) error {
+				calls = append(calls, name)
+				return next.HandleEvent(ctx, evt)
+			})
+		}
+	}
+
+	reg := NewEventRegistry()
+	reg.AddEventMiddleware("", trace("global"))
+	reg.AddEventMiddleware(InternalGroup, trace("internal"))
+
+	h := EventHandlerFunc(func(ctx context.Context, evt *Event) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	evtConfig := NewEventConfig("test", &middlewareEvent{})
+	for _, group := range []string{InternalGroup, ExternalGroup} {
+		if err := reg.AddGroupEventHandler(h, group, evtConfig); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	evt := &Event{Service: "test", Type: evtConfig.Name}
+	ctx := context.Background()
+	if err := reg.HandleGroupEvent(ctx, InternalGroup, evt); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := reg.HandleGroupEvent(ctx, ExternalGroup, evt); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := []string{"global", "internal", "handler", "global", "handler"}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, calls)
+		}
+	}
+}
@@ -15,6 +15,7 @@ var (
 var ErrInvalidTag = errors.New(`invalid tag`)
 
 type Filter struct {
+	Select   []string
 	Distinct []interface{}
 	Where    Where
 	Order    []Order
@@ -0,0 +1,173 @@
+package es
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// NamespaceExtractor gets the namespace of a request, empty leaves the default.
+type NamespaceExtractor func(r *http.Request) (string, error)
+
+// ActorExtractor gets the actor of a request, nil means anonymous.
+type ActorExtractor func(r *http.Request) (*Actor, error)
+
+type GatewayOption func(*gateway)
+
+// DefaultMaxBodyBytes is the largest command body the gateway reads.
+const DefaultMaxBodyBytes = 1 << 20
+
+// WithNamespaceExtractor sets how the namespace is read from a request.
+func WithNamespaceExtractor(fn NamespaceExtractor) GatewayOption {
+	return func(g *gateway) {
+		g.namespace = fn
+	}
+}
+
+// WithActorExtractor sets how the actor is read from a request.
+func WithActorExtractor(fn ActorExtractor) GatewayOption {
+	return func(g *gateway) {
+		g.actor = fn
+	}
+}
+
+// WithMaxBodyBytes sets the largest command body the gateway reads,
+// larger bodies are rejected with 413.
+func WithMaxBodyBytes(n int64) GatewayOption {
+	return func(g *gateway) {
+		g.maxBodyBytes = n
+	}
+}
+
+type gatewayError struct {
+	Error  string       `json:"error"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type gateway struct {
+	registry     Registry
+	namespace    NamespaceExtractor
+	actor        ActorExtractor
+	maxBodyBytes int64
+}
+
+func (g *gateway) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (g *gateway) writeError(w http.ResponseWriter, err error) {
+	out := gatewayError{Error: err.Error()}
+	status := http.StatusInternalServerError
+
+	var verr *ValidationError
+	switch {
+	case errors.As(err, &verr):
+		status = http.StatusBadRequest
+		out.Errors = verr.Errors
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrHandlerNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrConcurrency):
+		status = http.StatusConflict
+	}
+
+	// internal errors can leak details of the store.
+	if status == http.StatusInternalServerError {
+		out.Error = http.StatusText(status)
+	}
+	g.writeJSON(w, status, out)
+}
+
+func (g *gateway) handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cmdConfig, err := g.registry.GetCommandConfig(r.PathValue("name"))
+	if err == nil && cmdConfig.Internal {
+		err = fmt.Errorf("command %s: %w", cmdConfig.Name, ErrNotFound)
+	}
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	cmd, err := cmdConfig.Factory()
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, g.maxBodyBytes)
+	if err := json.NewDecoder(body).Decode(cmd); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			g.writeJSON(w, http.StatusRequestEntityTooLarge, gatewayError{Error: err.Error()})
+			return
+		}
+		g.writeError(w, &ValidationError{
+			Errors: []FieldError{{Message: fmt.Sprintf("invalid body: %s", err)}},
+			Err:    err,
+		})
+		return
+	}
+
+	if g.namespace != nil {
+		ns, err := g.namespace(r)
+		if err != nil {
+			g.writeJSON(w, http.StatusBadRequest, gatewayError{Error: err.Error()})
+			return
+		}
+		if ns != "" {
+			ctx = SetNamespace(ctx, ns)
+		}
+
+		// the body can't move the command to another tenant.
+		if nc, ok := cmd.(NamespaceCommand); ok && nc.GetNamespace() != "" && nc.GetNamespace() != GetNamespace(ctx) {
+			g.writeError(w, fmt.Errorf("namespace %s: %w", nc.GetNamespace(), ErrForbidden))
+			return
+		}
+	}
+	if g.actor != nil {
+		actor, err := g.actor(r)
+		if err != nil {
+			g.writeJSON(w, http.StatusUnauthorized, gatewayError{Error: err.Error()})
+			return
+		}
+		if actor != nil {
+			ctx = SetActor(ctx, actor)
+		}
+	}
+
+	unit, err := GetUnit(ctx)
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	results, err := unit.Dispatch(ctx, cmd)
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	result := results[0]
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, result.Version))
+	g.writeJSON(w, http.StatusOK, result)
+}
+
+// NewCommandGateway exposes POST /commands/{name} for every registered command.
+func NewCommandGateway(cli Client, opts ...GatewayOption) http.Handler {
+	g := &gateway{
+		registry:     cli.Registry(),
+		maxBodyBytes: DefaultMaxBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /commands/{name}", CreateUnit(cli)(http.HandlerFunc(g.handle)))
+	return mux
+}
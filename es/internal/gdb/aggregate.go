@@ -0,0 +1,76 @@
+package gdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-apis/eventsourcing/es"
+)
+
+func measureQuery(m es.Measure) (string, error) {
+	column := m.Column
+	if column == "" {
+		column = "*"
+	}
+
+	switch strings.ToLower(string(m.Func)) {
+	case `count`:
+		return fmt.Sprintf(`COUNT(%s) AS %s`, column, m.Name()), nil
+	case `sum`:
+		return fmt.Sprintf(`SUM(%s) AS %s`, column, m.Name()), nil
+	case `avg`:
+		return fmt.Sprintf(`AVG(%s) AS %s`, column, m.Name()), nil
+	case `min`:
+		return fmt.Sprintf(`MIN(%s) AS %s`, column, m.Name()), nil
+	case `max`:
+		return fmt.Sprintf(`MAX(%s) AS %s`, column, m.Name()), nil
+	default:
+		return ``, fmt.Errorf("unsupported aggregate function: %s", m.Func)
+	}
+}
+
+func bucketQuery(dialect string, column string, bucket es.DateBucket) (string, error) {
+	switch dialect {
+	case "postgres":
+		switch bucket {
+		case es.BucketHour, es.BucketDay, es.BucketWeek, es.BucketMonth, es.BucketYear:
+			return fmt.Sprintf(`date_trunc('%s', %s)`, bucket, column), nil
+		}
+	case "sqlite":
+		switch bucket {
+		case es.BucketHour:
+			return fmt.Sprintf(`strftime('%%Y-%%m-%%d %%H:00:00', %s)`, column), nil
+		case es.BucketDay:
+			return fmt.Sprintf(`strftime('%%Y-%%m-%%d', %s)`, column), nil
+		case es.BucketWeek:
+			return fmt.Sprintf(`date(%s, 'weekday 0', '-6 days')`, column), nil
+		case es.BucketMonth:
+			return fmt.Sprintf(`strftime('%%Y-%%m-01', %s)`, column), nil
+		case es.BucketYear:
+			return fmt.Sprintf(`strftime('%%Y-01-01', %s)`, column), nil
+		}
+	case "mysql":
+		switch bucket {
+		case es.BucketHour:
+			return fmt.Sprintf(`DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:00:00')`, column), nil
+		case es.BucketDay:
+			return fmt.Sprintf(`DATE(%s)`, column), nil
+		case es.BucketWeek:
+			return fmt.Sprintf(`DATE(DATE_SUB(%s, INTERVAL WEEKDAY(%s) DAY))`, column, column), nil
+		case es.BucketMonth:
+			return fmt.Sprintf(`DATE_FORMAT(%s, '%%Y-%%m-01')`, column), nil
+		case es.BucketYear:
+			return fmt.Sprintf(`DATE_FORMAT(%s, '%%Y-01-01')`, column), nil
+		}
+	default:
+		return ``, fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+	return ``, fmt.Errorf("unsupported date bucket: %s", bucket)
+}
+
+func groupByQuery(dialect string, g es.GroupBy) (string, error) {
+	if g.Bucket == "" {
+		return g.Column, nil
+	}
+	return bucketQuery(dialect, g.Column, g.Bucket)
+}
@@ -2,6 +2,7 @@ package gdb
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/go-apis/eventsourcing/es"
 	"go.opentelemetry.io/otel"
@@ -13,7 +14,7 @@ func AutoMigrate(ctx context.Context, db *gorm.DB, service string, reg es.Regist
 	_, pspan := otel.Tracer("local").Start(ctx, "Initialize")
 	defer pspan.End()
 
-	if err := db.AutoMigrate(&Event{}, &Snapshot{}, &PersistedCommand{}); err != nil {
+	if err := db.AutoMigrate(&Event{}, &Snapshot{}, &PersistedCommand{}, &DeadLetter{}, &ProcessedEvent{}); err != nil {
 		return err
 	}
 
@@ -40,6 +41,8 @@ type conn struct {
 	service        string
 	registry       es.Registry
 	db             *gorm.DB
+	replicas       []*gorm.DB
+	next           *atomic.Uint32
 	disableLocking bool
 }
 
@@ -48,13 +51,27 @@ func (c *conn) NewData(ctx context.Context) (es.Data, error) {
 	defer pspan.End()
 
 	db := c.db.WithContext(pctx)
-	return newData(c.service, db, c.registry, c.disableLocking), nil
+	replicas := make([]*gorm.DB, len(c.replicas))
+	for i, replica := range c.replicas {
+		replicas[i] = replica.WithContext(pctx)
+	}
+	return newData(c.service, db, replicas, c.next, c.registry, c.disableLocking), nil
 }
 
 func (c *conn) Close(ctx context.Context) error {
 	_, pspan := otel.Tracer("local").Start(ctx, "Close")
 	defer pspan.End()
 
+	for _, replica := range c.replicas {
+		sqlDB, err := replica.DB()
+		if err != nil {
+			return err
+		}
+		if err := sqlDB.Close(); err != nil {
+			return err
+		}
+	}
+
 	sqlDB, err := c.db.DB()
 	if err != nil {
 		return err
@@ -62,10 +79,12 @@ func (c *conn) Close(ctx context.Context) error {
 	return sqlDB.Close()
 }
 
-func NewConn(ctx context.Context, service string, db *gorm.DB, registry es.Registry, disableLocking bool) (es.Conn, error) {
+func NewConn(ctx context.Context, service string, db *gorm.DB, registry es.Registry, disableLocking bool, replicas ...*gorm.DB) (es.Conn, error) {
 	return &conn{
 		service:        service,
 		db:             db,
+		replicas:       replicas,
+		next:           &atomic.Uint32{},
 		registry:       registry,
 		disableLocking: disableLocking,
 	}, nil
@@ -4,8 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-apis/eventsourcing/es"
 	"github.com/google/uuid"
@@ -26,6 +30,8 @@ type data struct {
 	registry       es.Registry
 	db             *gorm.DB
 	tx             *gorm.DB
+	replicas       []*gorm.DB
+	next           *atomic.Uint32
 	disableLocking bool
 }
 
@@ -36,6 +42,15 @@ func (d *data) getDb() *gorm.DB {
 	return d.db
 }
 
+// getReadDb routes reads outside of a transaction to the replicas.
+func (d *data) getReadDb(ctx context.Context) *gorm.DB {
+	if d.tx != nil || len(d.replicas) == 0 || es.GetPrimaryOnly(ctx) {
+		return d.getDb()
+	}
+	i := d.next.Add(1)
+	return d.replicas[int(i)%len(d.replicas)]
+}
+
 func (d *data) Begin(ctx context.Context) (es.Tx, error) {
 	_, span := otel.Tracer("local").Start(ctx, "Begin")
 	defer span.End()
@@ -111,7 +126,7 @@ func (d *data) LoadSnapshot(ctx context.Context, search es.SnapshotSearch, out e
 	defer span.End()
 
 	var snapshot Snapshot
-	r := d.getDb().
+	r := d.getReadDb(pctx).
 		WithContext(pctx).
 		Model(&Snapshot{}).
 		Where("service_name = ?", d.service).
@@ -182,10 +197,23 @@ func (d *data) loadCommand(persisted *PersistedCommand) (es.Command, error) {
 
 	return cmd, nil
 }
+
+func (d *data) CreatePersistedCommand(ctx context.Context, cmd *es.PersistedCommand) error {
+	pctx, span := otel.Tracer("local").Start(ctx, "CreatePersistedCommand")
+	defer span.End()
+
+	// a redelivered event schedules the same id, keep the first one.
+	return d.savePersistedCommand(pctx, cmd, clause.OnConflict{DoNothing: true})
+}
+
 func (d *data) SavePersistedCommand(ctx context.Context, cmd *es.PersistedCommand) error {
 	pctx, span := otel.Tracer("local").Start(ctx, "SavePersistedCommand")
 	defer span.End()
 
+	return d.savePersistedCommand(pctx, cmd, clause.OnConflict{UpdateAll: true})
+}
+
+func (d *data) savePersistedCommand(ctx context.Context, cmd *es.PersistedCommand, conflict clause.OnConflict) error {
 	raw, err := json.Marshal(cmd.Command)
 	if err != nil {
 		return err
@@ -200,15 +228,77 @@ func (d *data) SavePersistedCommand(ctx context.Context, cmd *es.PersistedComman
 		ExecuteAfter: cmd.ExecuteAfter,
 		CreatedAt:    cmd.CreatedAt,
 		By:           cmd.By,
+		Recurrence:   cmd.Recurrence,
+		Metadata:     cmd.Metadata,
+		Trace:        cmd.Trace,
+		ScheduledBy:  cmd.ScheduledBy,
+
+		Attempts:       cmd.Attempts,
+		LastError:      cmd.LastError,
+		NextAttemptAt:  cmd.NextAttemptAt,
+		DeadLetteredAt: cmd.DeadLetteredAt,
+		LeasedBy:       cmd.LeasedBy,
+		LeaseExpiresAt: cmd.LeaseExpiresAt,
 	}
 
 	out := d.getDb().
-		WithContext(pctx).
-		Clauses(clause.OnConflict{
-			UpdateAll: true,
-		}).
+		WithContext(ctx).
+		Clauses(conflict).
 		Create(obj)
-	return out.Error
+	if out.Error != nil {
+		return out.Error
+	}
+
+	due := cmd.ExecuteAfter
+	if cmd.NextAttemptAt != nil {
+		due = *cmd.NextAttemptAt
+	}
+	return d.notify(ctx, due)
+}
+
+// notify wakes up schedulers, delivered once the transaction commits.
+func (d *data) notify(ctx context.Context, due time.Time) error {
+	db := d.getDb().WithContext(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return db.Exec("SELECT pg_notify(?, ?)", CommandsChannel(d.service), due.Format(time.RFC3339Nano)).Error
+}
+func (d *data) FindDeadLetteredCommands(ctx context.Context, filter es.Filter) ([]*es.PersistedCommand, error) {
+	deadLettered := es.WhereClause{Column: "dead_lettered_at", Op: es.OpNotIsNull}
+	if filter.Where == nil {
+		filter.Where = deadLettered
+	} else {
+		filter.Where = []es.Where{filter.Where, deadLettered}
+	}
+	return d.FindPersistedCommands(ctx, filter)
+}
+func (d *data) RedrivePersistedCommand(ctx context.Context, namespace string, id uuid.UUID) error {
+	pctx, span := otel.Tracer("local").Start(ctx, "RedrivePersistedCommand")
+	defer span.End()
+
+	now := time.Now()
+	out := d.getDb().
+		WithContext(pctx).
+		Model(&PersistedCommand{}).
+		Where("service_name = ?", d.service).
+		Where("namespace = ?", namespace).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":         0,
+			"last_error":       "",
+			"next_attempt_at":  now,
+			"dead_lettered_at": nil,
+			"leased_by":        "",
+			"lease_expires_at": nil,
+		})
+	if out.Error != nil {
+		return out.Error
+	}
+	if out.RowsAffected == 0 {
+		return fmt.Errorf("persisted command %s: %w", id, es.ErrNotFound)
+	}
+	return d.notify(pctx, now)
 }
 func (d *data) DeletePersistedCommand(ctx context.Context, cmd *es.PersistedCommand) error {
 	pctx, span := otel.Tracer("local").Start(ctx, "DeletePersistedCommand")
@@ -223,6 +313,103 @@ func (d *data) DeletePersistedCommand(ctx context.Context, cmd *es.PersistedComm
 		})
 	return out.Error
 }
+
+// SaveLeasedCommand updates a command only while owner still holds its lease.
+func (d *data) SaveLeasedCommand(ctx context.Context, owner string, cmd *es.PersistedCommand) error {
+	pctx, span := otel.Tracer("local").Start(ctx, "SaveLeasedCommand")
+	defer span.End()
+
+	out := d.leased(pctx, owner, cmd).
+		Updates(map[string]interface{}{
+			"execute_after":    cmd.ExecuteAfter,
+			"attempts":         cmd.Attempts,
+			"last_error":       cmd.LastError,
+			"next_attempt_at":  cmd.NextAttemptAt,
+			"dead_lettered_at": cmd.DeadLetteredAt,
+			"leased_by":        cmd.LeasedBy,
+			"lease_expires_at": cmd.LeaseExpiresAt,
+		})
+	if out.Error != nil {
+		return out.Error
+	}
+	if out.RowsAffected == 0 {
+		return fmt.Errorf("persisted command %s: %w", cmd.Id, es.ErrLeaseLost)
+	}
+
+	due := cmd.ExecuteAfter
+	if cmd.NextAttemptAt != nil {
+		due = *cmd.NextAttemptAt
+	}
+	return d.notify(pctx, due)
+}
+
+// DeleteLeasedCommand removes a command only while owner still holds its lease.
+func (d *data) DeleteLeasedCommand(ctx context.Context, owner string, cmd *es.PersistedCommand) error {
+	pctx, span := otel.Tracer("local").Start(ctx, "DeleteLeasedCommand")
+	defer span.End()
+
+	out := d.leased(pctx, owner, cmd).
+		Delete(&PersistedCommand{})
+	if out.Error != nil {
+		return out.Error
+	}
+	if out.RowsAffected == 0 {
+		return fmt.Errorf("persisted command %s: %w", cmd.Id, es.ErrLeaseLost)
+	}
+	return nil
+}
+
+func (d *data) leased(ctx context.Context, owner string, cmd *es.PersistedCommand) *gorm.DB {
+	return d.getDb().
+		WithContext(ctx).
+		Model(&PersistedCommand{}).
+		Where("service_name = ?", d.service).
+		Where("namespace = ?", cmd.Namespace).
+		Where("id = ?", cmd.Id).
+		Where("leased_by = ?", owner)
+}
+
+func (d *data) LeasePersistedCommands(ctx context.Context, owner string, due time.Time, ttl time.Duration, limit int) ([]*es.PersistedCommand, error) {
+	pctx, span := otel.Tracer("local").Start(ctx, "LeasePersistedCommands")
+	defer span.End()
+
+	db := d.getDb().WithContext(pctx)
+	now := time.Now()
+
+	candidates := db.
+		Model(&PersistedCommand{}).
+		Select("id").
+		Where("service_name = ?", d.service).
+		Where("COALESCE(next_attempt_at, execute_after) < ?", due).
+		Where("dead_lettered_at IS NULL").
+		Where("(lease_expires_at IS NULL OR lease_expires_at < ?)", now).
+		Order("COALESCE(next_attempt_at, execute_after)").
+		Limit(limit)
+	if db.Dialector.Name() == "postgres" {
+		// rows being leased by another scheduler are skipped rather than waited on.
+		candidates = candidates.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+
+	out := db.
+		Model(&PersistedCommand{}).
+		Where("service_name = ?", d.service).
+		Where("id IN (?)", candidates).
+		Updates(map[string]interface{}{
+			"leased_by":        owner,
+			"lease_expires_at": now.Add(ttl),
+		})
+	if out.Error != nil {
+		return nil, out.Error
+	}
+
+	return d.FindPersistedCommands(pctx, es.Filter{
+		Where: []es.WhereClause{
+			{Column: "leased_by", Op: es.OpEqual, Args: owner},
+			{Column: "lease_expires_at", Op: es.OpGreaterThan, Args: now},
+		},
+		Order: []es.Order{{Expression: "COALESCE(next_attempt_at, execute_after)"}},
+	})
+}
 func (d *data) FindPersistedCommands(ctx context.Context, filter es.Filter) ([]*es.PersistedCommand, error) {
 	pctx, span := otel.Tracer("local").Start(ctx, "FindPersistedCommands")
 	defer span.End()
@@ -277,11 +464,127 @@ func (d *data) FindPersistedCommands(ctx context.Context, filter es.Filter) ([]*
 			ExecuteAfter: scanned.ExecuteAfter,
 			CreatedAt:    scanned.CreatedAt,
 			By:           scanned.By,
+			Recurrence:   scanned.Recurrence,
+			Metadata:     scanned.Metadata,
+			Trace:        scanned.Trace,
+			ScheduledBy:  scanned.ScheduledBy,
+
+			Attempts:       scanned.Attempts,
+			LastError:      scanned.LastError,
+			NextAttemptAt:  scanned.NextAttemptAt,
+			DeadLetteredAt: scanned.DeadLetteredAt,
+			LeasedBy:       scanned.LeasedBy,
+			LeaseExpiresAt: scanned.LeaseExpiresAt,
 		})
 	}
 
 	return cmds, nil
 }
+func (d *data) MarkEventProcessed(ctx context.Context, group string, evt *es.Event) (bool, error) {
+	pctx, span := otel.Tracer("local").Start(ctx, "MarkEventProcessed")
+	defer span.End()
+
+	out := d.getDb().
+		WithContext(pctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&ProcessedEvent{
+			ServiceName:   d.service,
+			Group:         group,
+			EventService:  evt.Service,
+			Namespace:     evt.Namespace,
+			AggregateType: evt.AggregateType,
+			AggregateId:   evt.AggregateId,
+			Version:       evt.Version,
+			ProcessedAt:   time.Now(),
+		})
+	if out.Error != nil {
+		return false, out.Error
+	}
+	return out.RowsAffected > 0, nil
+}
+func (d *data) DeleteProcessedEvents(ctx context.Context, before time.Time) (int, error) {
+	pctx, span := otel.Tracer("local").Start(ctx, "DeleteProcessedEvents")
+	defer span.End()
+
+	out := d.getDb().
+		WithContext(pctx).
+		Where("service_name = ?", d.service).
+		Where("processed_at < ?", before).
+		Delete(&ProcessedEvent{})
+	if out.Error != nil {
+		return 0, out.Error
+	}
+	return int(out.RowsAffected), nil
+}
+func (d *data) SaveDeadLetter(ctx context.Context, deadLetter *es.DeadLetter) error {
+	pctx, span := otel.Tracer("local").Start(ctx, "SaveDeadLetter")
+	defer span.End()
+
+	out := d.getDb().
+		WithContext(pctx).
+		Create(&DeadLetter{
+			ServiceName: d.service,
+			Id:          deadLetter.Id,
+			Group:       deadLetter.Group,
+			Payload:     deadLetter.Payload,
+			Error:       deadLetter.Error,
+			Attempts:    deadLetter.Attempts,
+			CreatedAt:   deadLetter.CreatedAt,
+		})
+	return out.Error
+}
+func (d *data) FindDeadLetters(ctx context.Context, filter es.Filter) ([]*es.DeadLetter, error) {
+	pctx, span := otel.Tracer("local").Start(ctx, "FindDeadLetters")
+	defer span.End()
+
+	q := d.getDb().
+		WithContext(pctx).
+		Model(&DeadLetter{}).
+		Where("service_name = ?", d.service)
+
+	if filter.Where != nil {
+		q = where(q, filter.Where)
+	}
+	if filter.Limit != nil {
+		q = q.Limit(*filter.Limit)
+	}
+	if filter.Offset != nil {
+		q = q.Offset(*filter.Offset)
+	}
+	for _, order := range filter.Order {
+		q = q.Order(fmt.Sprintf("%s %s", order.Expression, strings.ToUpper(string(order.Direction))))
+	}
+
+	var scanned []*DeadLetter
+	if err := q.Find(&scanned).Error; err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]*es.DeadLetter, len(scanned))
+	for i, s := range scanned {
+		deadLetters[i] = &es.DeadLetter{
+			Id:        s.Id,
+			Group:     s.Group,
+			Payload:   s.Payload,
+			Error:     s.Error,
+			Attempts:  s.Attempts,
+			CreatedAt: s.CreatedAt,
+		}
+	}
+	return deadLetters, nil
+}
+func (d *data) DeleteDeadLetter(ctx context.Context, id uuid.UUID) error {
+	pctx, span := otel.Tracer("local").Start(ctx, "DeleteDeadLetter")
+	defer span.End()
+
+	out := d.getDb().
+		WithContext(pctx).
+		Delete(&DeadLetter{
+			ServiceName: d.service,
+			Id:          id,
+		})
+	return out.Error
+}
 
 func (d *data) loadEventData(evt *Event) (interface{}, error) {
 	eventConfig, err := d.registry.GetEventConfig(evt.ServiceName, evt.Type)
@@ -305,7 +608,7 @@ func (d *data) FindEvents(ctx context.Context, filter es.Filter) ([]*es.Event, e
 	pctx, span := otel.Tracer("local").Start(ctx, "GetEvents")
 	defer span.End()
 
-	q := d.getDb().
+	q := d.getReadDb(pctx).
 		WithContext(pctx).
 		Model(&Event{}).
 		Where("service_name = ?", d.service)
@@ -394,6 +697,9 @@ func (d *data) SaveEvents(ctx context.Context, events []*es.Event) error {
 	out := d.getDb().
 		WithContext(pctx).
 		Create(&evts)
+	if d.isDuplicate(out.Error) {
+		return fmt.Errorf("%w: %s", es.ErrConcurrency, out.Error)
+	}
 	return out.Error
 }
 func (d *data) SaveEntity(ctx context.Context, aggregateName string, raw es.Entity) error {
@@ -401,6 +707,10 @@ func (d *data) SaveEntity(ctx context.Context, aggregateName string, raw es.Enti
 	defer span.End()
 
 	table := TableName(d.service, aggregateName)
+	if versioned, ok := raw.(es.VersionedEntity); ok {
+		return d.saveVersionedEntity(pctx, table, versioned)
+	}
+
 	out := d.getDb().
 		WithContext(pctx).
 		Table(table).
@@ -411,6 +721,34 @@ func (d *data) SaveEntity(ctx context.Context, aggregateName string, raw es.Enti
 		Create(raw)
 	return out.Error
 }
+
+// saveVersionedEntity bumps the version and fails when someone else
+// saved the entity since it was loaded.
+func (d *data) saveVersionedEntity(ctx context.Context, table string, raw es.VersionedEntity) error {
+	version := raw.GetVersion()
+	raw.SetVersion(version + 1)
+
+	db := d.getDb().WithContext(ctx).Table(table)
+	var out *gorm.DB
+	if version == 0 {
+		out = db.
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(raw)
+	} else {
+		out = db.
+			Where("namespace = ?", raw.GetNamespace()).
+			Where("version = ?", version).
+			Select("*").
+			Updates(raw)
+	}
+	if out.Error == nil && out.RowsAffected == 0 {
+		out.Error = fmt.Errorf("%s %s version %d: %w", table, raw.GetId(), version, es.ErrConcurrency)
+	}
+	if out.Error != nil {
+		raw.SetVersion(version)
+	}
+	return out.Error
+}
 func (d *data) DeleteEntity(ctx context.Context, aggregateName string, raw es.Entity) error {
 	pctx, span := otel.Tracer("local").Start(ctx, "DeleteEntity")
 	defer span.End()
@@ -427,10 +765,13 @@ func (d *data) Truncate(ctx context.Context, aggregateName string) error {
 	defer span.End()
 
 	table := TableName(d.service, aggregateName)
-	out := d.getDb().
-		WithContext(pctx).
-		Raw(fmt.Sprintf("TRUNCATE TABLE %s", table))
-	return out.Error
+	db := d.getDb().WithContext(pctx)
+
+	stmt := fmt.Sprintf("TRUNCATE TABLE %s", table)
+	if db.Dialector.Name() == "sqlite" {
+		stmt = fmt.Sprintf("DELETE FROM %s", table)
+	}
+	return db.Exec(stmt).Error
 }
 func (d *data) Get(ctx context.Context, aggregateName string, namespace string, id uuid.UUID, out interface{}) error {
 	pctx, span := otel.Tracer("local").Start(ctx, "Load")
@@ -438,7 +779,7 @@ func (d *data) Get(ctx context.Context, aggregateName string, namespace string,
 
 	table := TableName(d.service, aggregateName)
 
-	q := d.getDb().
+	q := d.getReadDb(pctx).
 		WithContext(pctx).
 		Table(table).
 		Where("id = ?", id)
@@ -459,7 +800,7 @@ func (d *data) One(ctx context.Context, aggregateName string, namespace string,
 
 	table := TableName(d.service, aggregateName)
 
-	q := d.getDb().
+	q := d.getReadDb(pctx).
 		WithContext(pctx).
 		Table(table)
 
@@ -473,6 +814,8 @@ func (d *data) One(ctx context.Context, aggregateName string, namespace string,
 		q = q.Distinct(filter.Distinct...)
 	}
 
+	q = d.selectColumns(q, aggregateName, filter, out)
+
 	r := q.Limit(1).Find(out)
 	if r.RowsAffected == 0 {
 		return sql.ErrNoRows
@@ -484,7 +827,7 @@ func (d *data) Find(ctx context.Context, aggregateName string, namespace string,
 	defer span.End()
 
 	table := TableName(d.service, aggregateName)
-	q := d.getDb().
+	q := d.getReadDb(pctx).
 		WithContext(pctx).
 		Table(table)
 
@@ -509,6 +852,8 @@ func (d *data) Find(ctx context.Context, aggregateName string, namespace string,
 		q = q.Order(fmt.Sprintf("%s %s", order.Expression, strings.ToUpper(string(order.Direction))))
 	}
 
+	q = d.selectColumns(q, aggregateName, filter, out)
+
 	r := q.
 		Find(out)
 	return r.Error
@@ -520,7 +865,7 @@ func (d *data) Count(ctx context.Context, aggregateName string, namespace string
 	var totalRows int64
 
 	table := TableName(d.service, aggregateName)
-	q := d.getDb().
+	q := d.getReadDb(pctx).
 		WithContext(pctx).
 		Table(table)
 
@@ -537,11 +882,116 @@ func (d *data) Count(ctx context.Context, aggregateName string, namespace string
 	r := q.Count(&totalRows)
 	return int(totalRows), r.Error
 }
+func (d *data) Aggregate(ctx context.Context, aggregateName string, namespace string, filter es.Filter, groupBy []es.GroupBy, measures []es.Measure) ([]es.AggregateRow, error) {
+	pctx, span := otel.Tracer("local").Start(ctx, "Aggregate")
+	defer span.End()
+
+	db := d.getReadDb(pctx)
+	dialect := db.Dialector.Name()
+
+	var selects []string
+	var groups []string
+	for _, g := range groupBy {
+		expr, err := groupByQuery(dialect, g)
+		if err != nil {
+			return nil, err
+		}
+		selects = append(selects, fmt.Sprintf("%s AS %s", expr, g.Name()))
+		groups = append(groups, expr)
+	}
+	for _, m := range measures {
+		expr, err := measureQuery(m)
+		if err != nil {
+			return nil, err
+		}
+		selects = append(selects, expr)
+	}
+
+	table := TableName(d.service, aggregateName)
+	q := db.
+		WithContext(pctx).
+		Table(table).
+		Select(strings.Join(selects, ", "))
+
+	q = where(q, filter.Where)
+
+	if namespace != "" {
+		q = q.Where("namespace = ?", namespace)
+	}
+
+	for _, group := range groups {
+		q = q.Group(group)
+	}
+
+	if filter.Limit != nil {
+		q = q.Limit(*filter.Limit)
+	}
+	if filter.Offset != nil {
+		q = q.Offset(*filter.Offset)
+	}
+
+	for _, order := range filter.Order {
+		q = q.Order(fmt.Sprintf("%s %s", order.Expression, strings.ToUpper(string(order.Direction))))
+	}
+
+	var rows []map[string]interface{}
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]es.AggregateRow, len(rows))
+	for i, row := range rows {
+		// computed columns have no type information so gorm hands them back as pointers.
+		for k, v := range row {
+			if p, ok := v.(*interface{}); ok && p != nil {
+				row[k] = *p
+			}
+		}
+		out[i] = es.AggregateRow(row)
+	}
+	return out, nil
+}
+
+// selectColumns applies the filter's selected columns, otherwise when
+// scanning into a type other than the entity only its fields are fetched.
+func (d *data) selectColumns(q *gorm.DB, aggregateName string, filter es.Filter, out interface{}) *gorm.DB {
+	if len(filter.Select) > 0 {
+		return q.Select(filter.Select)
+	}
+
+	entityConfig, err := d.registry.GetEntityConfig(aggregateName)
+	if err != nil {
+		return q
+	}
+
+	t := reflect.TypeOf(out)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t == entityConfig.Type {
+		return q
+	}
+	return q.Session(&gorm.Session{QueryFields: true})
+}
+
+// isDuplicate translates err with the dialect, the handle we were given
+// may not translate errors itself.
+func (d *data) isDuplicate(err error) bool {
+	if err == nil {
+		return false
+	}
+	if translator, ok := d.db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
 
-func newData(service string, db *gorm.DB, registry es.Registry, disableLocking bool) es.Data {
+func newData(service string, db *gorm.DB, replicas []*gorm.DB, next *atomic.Uint32, registry es.Registry, disableLocking bool) es.Data {
 	d := &data{
 		service:        service,
 		db:             db,
+		replicas:       replicas,
+		next:           next,
 		registry:       registry,
 		disableLocking: disableLocking,
 	}
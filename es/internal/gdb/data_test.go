@@ -0,0 +1,66 @@
+package gdb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-apis/eventsourcing/es"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openMemory(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func Test_GetReadDb(t *testing.T) {
+	ctx := context.Background()
+	primary := openMemory(t)
+	replicas := []*gorm.DB{openMemory(t), openMemory(t)}
+
+	t.Run("Without_Replicas", func(t *testing.T) {
+		d := newData("test", primary, nil, &atomic.Uint32{}, nil, true).(*data)
+		if d.getReadDb(ctx) != primary {
+			t.Error("expected reads to use the primary")
+		}
+	})
+
+	t.Run("Round_Robin", func(t *testing.T) {
+		d := newData("test", primary, replicas, &atomic.Uint32{}, nil, true).(*data)
+
+		seen := map[*gorm.DB]int{}
+		for i := 0; i < 4; i++ {
+			seen[d.getReadDb(ctx)]++
+		}
+		if seen[primary] != 0 || seen[replicas[0]] != 2 || seen[replicas[1]] != 2 {
+			t.Errorf("expected reads to alternate between the replicas, got %v", seen)
+		}
+	})
+
+	t.Run("Primary_Only", func(t *testing.T) {
+		d := newData("test", primary, replicas, &atomic.Uint32{}, nil, true).(*data)
+		if d.getReadDb(es.SetPrimaryOnly(ctx)) != primary {
+			t.Error("expected primary only reads to use the primary")
+		}
+	})
+
+	t.Run("In_Transaction", func(t *testing.T) {
+		d := newData("test", primary, replicas, &atomic.Uint32{}, nil, true).(*data)
+
+		tx, err := d.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback(ctx)
+
+		// reads must see the transaction's own writes.
+		if got := d.getReadDb(ctx); got != d.tx {
+			t.Errorf("expected reads to use the transaction, got %p", got)
+		}
+	})
+}
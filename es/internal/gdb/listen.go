@@ -0,0 +1,96 @@
+package gdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-apis/eventsourcing/es"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// listen holds a dedicated connection until the context is done.
+func (c *conn) listen(ctx context.Context, channel string) (<-chan *Notification, error) {
+	if c.db.Dialector.Name() != "postgres" {
+		return nil, es.ErrListenNotSupported
+	}
+
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *Notification)
+	ready := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer raw.Close()
+
+		err := raw.Raw(func(driverConn interface{}) error {
+			pgConn, ok := driverConn.(*stdlib.Conn)
+			if !ok {
+				ready <- fmt.Errorf("%w: %T", es.ErrListenNotSupported, driverConn)
+				return nil
+			}
+
+			if _, err := pgConn.Conn().Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+				ready <- err
+				return err
+			}
+			ready <- nil
+
+			for {
+				n, err := pgConn.Conn().WaitForNotification(ctx)
+				if err != nil {
+					return err
+				}
+
+				select {
+				case out <- &Notification{PID: n.PID, Channel: n.Channel, Payload: n.Payload}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		})
+		// in case the connection failed before listening.
+		select {
+		case ready <- err:
+		default:
+		}
+	}()
+
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conn) ListenPersistedCommands(ctx context.Context) (<-chan time.Time, error) {
+	notifications, err := c.listen(ctx, CommandsChannel(c.service))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan time.Time)
+	go func() {
+		defer close(out)
+
+		for n := range notifications {
+			t, err := time.Parse(time.RFC3339Nano, n.Payload)
+			if err != nil {
+				continue
+			}
+			select {
+			case out <- t:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -54,6 +54,45 @@ type PersistedCommand struct {
 	ExecuteAfter time.Time       `json:"execute_after"`
 	CreatedAt    time.Time       `json:"created_at"`
 	By           *es.Actor       `json:"by" gorm:"type:jsonb;serializer:json"`
+	Recurrence   string          `json:"recurrence"`
+
+	Metadata    map[string]interface{} `json:"metadata" gorm:"type:jsonb;serializer:json"`
+	Trace       map[string]string      `json:"trace" gorm:"type:jsonb;serializer:json"`
+	ScheduledBy *es.ScheduledBy        `json:"scheduled_by" gorm:"type:jsonb;serializer:json"`
+
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at"`
+	LeasedBy       string     `json:"leased_by"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+}
+
+type DeadLetter struct {
+	ServiceName string    `json:"service_name" gorm:"primaryKey"`
+	Id          uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
+	Group       string    `json:"group" gorm:"column:group_name;index"`
+	Payload     []byte    `json:"payload"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProcessedEvent marks an event as handled by a group.
+type ProcessedEvent struct {
+	ServiceName   string    `json:"service_name" gorm:"primaryKey"`
+	Group         string    `json:"group" gorm:"column:group_name;primaryKey"`
+	EventService  string    `json:"event_service" gorm:"primaryKey"`
+	Namespace     string    `json:"namespace" gorm:"primaryKey"`
+	AggregateType string    `json:"aggregate_type" gorm:"primaryKey"`
+	AggregateId   uuid.UUID `json:"aggregate_id" gorm:"primaryKey;type:uuid"`
+	Version       int       `json:"version" gorm:"primaryKey"`
+	ProcessedAt   time.Time `json:"processed_at" gorm:"index"`
+}
+
+// CommandsChannel is notified with the execute after time of saved persisted commands.
+func CommandsChannel(service string) string {
+	return strings.ToLower(service) + "_persisted_commands"
 }
 
 func TableName(service string, aggregateName string) string {
@@ -0,0 +1,610 @@
+package ops
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-apis/eventsourcing/es"
+	"github.com/google/uuid"
+)
+
+const batchSize = 500
+
+// ErrNotProjection is returned when rebuilding an entity that nothing projects to.
+var ErrNotProjection = errors.New("not a projection")
+
+// EventQuery narrows down the events to look at, empty fields are ignored.
+type EventQuery struct {
+	Namespace     string
+	AggregateType string
+	AggregateId   uuid.UUID
+	Type          string
+	Limit         int
+	Offset        int
+}
+
+func (q EventQuery) Filter() es.Filter {
+	var where []es.WhereClause
+	add := func(column string, value interface{}) {
+		where = append(where, es.WhereClause{Column: column, Op: es.OpEqual, Args: value})
+	}
+	if q.Namespace != "" {
+		add("namespace", q.Namespace)
+	}
+	if q.AggregateType != "" {
+		add("aggregate_type", q.AggregateType)
+	}
+	if q.AggregateId != uuid.Nil {
+		add("aggregate_id", q.AggregateId.String())
+	}
+	if q.Type != "" {
+		add("type", q.Type)
+	}
+
+	filter := es.Filter{
+		Order: []es.Order{
+			{Expression: "timestamp"},
+			{Expression: "aggregate_type"},
+			{Expression: "aggregate_id"},
+			{Expression: "version"},
+		},
+	}
+	if len(where) > 0 {
+		filter.Where = where
+	}
+	if q.Limit > 0 {
+		filter.Limit = es.Limit(q.Limit)
+	}
+	if q.Offset > 0 {
+		filter.Offset = es.Offset(q.Offset)
+	}
+	return filter
+}
+
+// AggregateStream is the current state of an aggregate and the events it was built from.
+// Snapshot is the latest snapshot if there is one and Rows are the projected
+// rows of every entity with the same id.
+type AggregateStream struct {
+	Aggregate es.Entity            `json:"aggregate"`
+	Events    []*es.Event          `json:"events"`
+	Snapshot  es.Entity            `json:"snapshot,omitempty"`
+	Rows      map[string]es.Entity `json:"rows,omitempty"`
+}
+
+// Record is a single line of a namespace dump.
+type Record struct {
+	Event   *es.Event            `json:"event,omitempty"`
+	Command *es.PersistedCommand `json:"command,omitempty"`
+}
+
+type rawRecord struct {
+	Event *struct {
+		*es.Event
+		Data json.RawMessage `json:"data"`
+	} `json:"event"`
+	Command *struct {
+		*es.PersistedCommand
+		Command json.RawMessage `json:"command"`
+	} `json:"command"`
+}
+
+// Operator inspects and repairs the event store of a service.
+type Operator struct {
+	cli es.Client
+}
+
+func (o *Operator) unit(ctx context.Context) (context.Context, es.Unit, error) {
+	unit, err := o.cli.Unit(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return es.SetUnit(ctx, unit), unit, nil
+}
+
+func (o *Operator) work(ctx context.Context, fn func(ctx context.Context, unit es.Unit) error) error {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return unit.Work(ctx, func(ctx context.Context) error {
+		return fn(ctx, unit)
+	})
+}
+
+// FindEvents matching the query ordered by time.
+func (o *Operator) FindEvents(ctx context.Context, query EventQuery) ([]*es.Event, error) {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return unit.FindEvents(ctx, query.Filter())
+}
+
+// LoadAggregate returns the current state and the stream of an aggregate.
+func (o *Operator) LoadAggregate(ctx context.Context, name string, namespace string, id uuid.UUID) (*AggregateStream, error) {
+	ctx, unit, err := o.unit(es.SetNamespace(ctx, namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	agg, err := unit.Load(ctx, name, id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := unit.FindEvents(ctx, EventQuery{
+		Namespace:     namespace,
+		AggregateType: name,
+		AggregateId:   id,
+	}.Filter())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := o.loadSnapshot(ctx, unit, name, namespace, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[string]es.Entity{}
+	for _, entityConfig := range o.cli.Registry().GetEntities() {
+		row, err := entityConfig.Factory()
+		if err != nil {
+			return nil, err
+		}
+		// not every entity is projected to a table.
+		if err := unit.Get(ctx, entityConfig.Name, namespace, id, row); err != nil {
+			continue
+		}
+		rows[entityConfig.Name] = row
+	}
+
+	return &AggregateStream{
+		Aggregate: agg,
+		Events:    events,
+		Snapshot:  snapshot,
+		Rows:      rows,
+	}, nil
+}
+
+func (o *Operator) loadSnapshot(ctx context.Context, unit es.Unit, name string, namespace string, id uuid.UUID) (es.Entity, error) {
+	entityConfig, err := o.cli.Registry().GetEntityConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	if !entityConfig.SnapshotEnabled {
+		return nil, nil
+	}
+
+	entity, err := entityConfig.Factory()
+	if err != nil {
+		return nil, err
+	}
+	agg, ok := entity.(es.AggregateSourced)
+	if !ok {
+		return nil, nil
+	}
+
+	search := es.SnapshotSearch{
+		Namespace:     namespace,
+		AggregateType: entityConfig.Name,
+		AggregateId:   id,
+		Revision:      entityConfig.SnapshotRevision,
+	}
+	if err := unit.Data().LoadSnapshot(ctx, search, agg); err != nil {
+		return nil, err
+	}
+	if agg.GetVersion() == 0 {
+		return nil, nil
+	}
+	return agg, nil
+}
+
+func (o *Operator) distinct(ctx context.Context, column string, where es.Where) ([]*es.Event, error) {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return unit.FindEvents(ctx, es.Filter{
+		Distinct: []interface{}{column},
+		Where:    where,
+		Order:    []es.Order{{Expression: column}},
+	})
+}
+
+// Namespaces that have events.
+func (o *Operator) Namespaces(ctx context.Context) ([]string, error) {
+	events, err := o.distinct(ctx, "namespace", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, len(events))
+	for i, evt := range events {
+		namespaces[i] = evt.Namespace
+	}
+	return namespaces, nil
+}
+
+// AggregateTypes that have events in the namespace.
+func (o *Operator) AggregateTypes(ctx context.Context, namespace string) ([]string, error) {
+	events, err := o.distinct(ctx, "aggregate_type", es.WhereClause{Column: "namespace", Op: es.OpEqual, Args: namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, len(events))
+	for i, evt := range events {
+		types[i] = evt.AggregateType
+	}
+	return types, nil
+}
+
+// ListCommands that are waiting to be executed, optionally for one namespace.
+func (o *Operator) ListCommands(ctx context.Context, namespace string) ([]*es.PersistedCommand, error) {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := es.Filter{
+		Order: []es.Order{{Expression: "execute_after"}},
+	}
+	if namespace != "" {
+		filter.Where = es.WhereClause{Column: "namespace", Op: es.OpEqual, Args: namespace}
+	}
+	return unit.Data().FindPersistedCommands(ctx, filter)
+}
+
+// CancelCommand removes a persisted command before it runs.
+func (o *Operator) CancelCommand(ctx context.Context, namespace string, id uuid.UUID) error {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return err
+	}
+	return unit.Data().DeletePersistedCommand(ctx, &es.PersistedCommand{
+		Id:        id,
+		Namespace: namespace,
+	})
+}
+
+// ListDeadLetters are the persisted commands that ran out of attempts.
+func (o *Operator) ListDeadLetters(ctx context.Context, namespace string) ([]*es.PersistedCommand, error) {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := es.Filter{
+		Order: []es.Order{{Expression: "dead_lettered_at"}},
+	}
+	if namespace != "" {
+		filter.Where = es.WhereClause{Column: "namespace", Op: es.OpEqual, Args: namespace}
+	}
+	return unit.Data().FindDeadLetteredCommands(ctx, filter)
+}
+
+// Redrive resets the attempts of a persisted command so it runs again.
+func (o *Operator) Redrive(ctx context.Context, namespace string, id uuid.UUID) error {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return err
+	}
+	return unit.Data().RedrivePersistedCommand(ctx, namespace, id)
+}
+
+// ListEventDeadLetters are the messages the group's handlers gave up on,
+// every group's when group is empty.
+func (o *Operator) ListEventDeadLetters(ctx context.Context, group string) ([]*es.DeadLetter, error) {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := es.Filter{
+		Order: []es.Order{{Expression: "created_at"}},
+	}
+	if group != "" {
+		filter.Where = es.WhereClause{Column: "group_name", Op: es.OpEqual, Args: group}
+	}
+	return unit.Data().FindDeadLetters(ctx, filter)
+}
+
+// RedriveEvent handles a dead lettered message again and removes it once it succeeds.
+func (o *Operator) RedriveEvent(ctx context.Context, id uuid.UUID) error {
+	return o.work(ctx, func(ctx context.Context, unit es.Unit) error {
+		deadLetters, err := unit.Data().FindDeadLetters(ctx, es.Filter{
+			Where: es.WhereClause{Column: "id", Op: es.OpEqual, Args: id},
+		})
+		if err != nil {
+			return err
+		}
+		if len(deadLetters) == 0 {
+			return fmt.Errorf("dead letter %s: %w", id, es.ErrNotFound)
+		}
+		deadLetter := deadLetters[0]
+
+		evt, err := o.cli.Registry().ParseEvent(ctx, deadLetter.Payload)
+		if err != nil {
+			return err
+		}
+		if evt.By != nil {
+			ctx = es.SetActor(ctx, evt.By)
+		}
+		if err := unit.Handle(ctx, deadLetter.Group, evt); err != nil {
+			return err
+		}
+		return unit.Data().DeleteDeadLetter(ctx, deadLetter.Id)
+	})
+}
+
+// PurgeProcessedEvents forgets which events the groups handled before the
+// given time, keep them for longer than the broker can redeliver a message.
+// It returns the number of markers removed.
+func (o *Operator) PurgeProcessedEvents(ctx context.Context, before time.Time) (int, error) {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return unit.Data().DeleteProcessedEvents(ctx, before)
+}
+
+// Replay rebuilds an aggregate from its events and saves it again.
+func (o *Operator) Replay(ctx context.Context, name string, namespace string, id uuid.UUID) error {
+	ctx, unit, err := o.unit(es.SetNamespace(ctx, namespace))
+	if err != nil {
+		return err
+	}
+	_, err = unit.Dispatch(ctx, es.NewReplayCommand(namespace, id, name))
+	return err
+}
+
+// Projections are the entities projectors write to.
+func (o *Operator) Projections() []string {
+	return o.cli.Registry().GetProjections()
+}
+
+// IsProjection reports whether projectors write to the entity.
+func (o *Operator) IsProjection(name string) bool {
+	for _, projection := range o.Projections() {
+		if strings.EqualFold(projection, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RebuildProjection truncates the entity and fills it again. Projector targets
+// run every event through their projectors, projected sourced aggregates are
+// loaded from their events and saved. Anything else is left alone and
+// ErrNotProjection is returned. It returns the number of events or aggregates
+// that were read.
+func (o *Operator) RebuildProjection(ctx context.Context, name string) (int, error) {
+	if o.IsProjection(name) {
+		return o.rebuildProjector(ctx, name)
+	}
+
+	entityConfig, err := o.cli.Registry().GetEntityConfig(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, ErrNotProjection)
+	}
+	entity, err := entityConfig.Factory()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := entity.(es.AggregateSourced); !ok || !entityConfig.Project {
+		return 0, fmt.Errorf("%s: %w", name, ErrNotProjection)
+	}
+	return o.rebuildAggregates(ctx, entityConfig)
+}
+
+func (o *Operator) rebuildProjector(ctx context.Context, name string) (int, error) {
+	reg := o.cli.Registry()
+
+	total := 0
+	err := o.work(ctx, func(ctx context.Context, unit es.Unit) error {
+		if err := unit.Truncate(ctx, name); err != nil {
+			return err
+		}
+
+		for offset := 0; ; offset += batchSize {
+			events, err := unit.FindEvents(ctx, EventQuery{Limit: batchSize, Offset: offset}.Filter())
+			if err != nil {
+				return err
+			}
+			for _, evt := range events {
+				if err := reg.HandleProjectionEvent(ctx, name, evt); err != nil {
+					return err
+				}
+			}
+			total += len(events)
+			if len(events) < batchSize {
+				return nil
+			}
+		}
+	})
+	return total, err
+}
+
+func (o *Operator) rebuildAggregates(ctx context.Context, entityConfig *es.EntityConfig) (int, error) {
+	total := 0
+	err := o.work(ctx, func(ctx context.Context, unit es.Unit) error {
+		if err := unit.Truncate(ctx, entityConfig.Name); err != nil {
+			return err
+		}
+
+		for offset := 0; ; offset += batchSize {
+			aggregates, err := unit.FindEvents(ctx, es.Filter{
+				Distinct: []interface{}{"namespace", "aggregate_id"},
+				Where:    es.WhereClause{Column: "aggregate_type", Op: es.OpEqual, Args: entityConfig.Name},
+				Order:    []es.Order{{Expression: "namespace"}, {Expression: "aggregate_id"}},
+				Limit:    es.Limit(batchSize),
+				Offset:   es.Offset(offset),
+			})
+			if err != nil {
+				return err
+			}
+			for _, evt := range aggregates {
+				nctx := es.SetNamespace(ctx, evt.Namespace)
+				aggregate, err := unit.Load(nctx, entityConfig.Name, evt.AggregateId, es.DataLoadForce(true))
+				if err != nil {
+					return err
+				}
+				if err := unit.Data().SaveEntity(nctx, entityConfig.Name, aggregate); err != nil {
+					return err
+				}
+			}
+			total += len(aggregates)
+			if len(aggregates) < batchSize {
+				return nil
+			}
+		}
+	})
+	return total, err
+}
+
+// DumpNamespace writes the events and persisted commands of a namespace as json lines.
+func (o *Operator) DumpNamespace(ctx context.Context, namespace string, w io.Writer) error {
+	ctx, unit, err := o.unit(ctx)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for offset := 0; ; offset += batchSize {
+		events, err := unit.FindEvents(ctx, EventQuery{Namespace: namespace, Limit: batchSize, Offset: offset}.Filter())
+		if err != nil {
+			return err
+		}
+		for _, evt := range events {
+			if err := enc.Encode(&Record{Event: evt}); err != nil {
+				return err
+			}
+		}
+		if len(events) < batchSize {
+			break
+		}
+	}
+
+	cmds, err := o.ListCommands(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range cmds {
+		if err := enc.Encode(&Record{Command: cmd}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreNamespace reads a dump into the namespace, or into the namespaces
+// of the dump when empty, and replays every aggregate it contains.
+func (o *Operator) RestoreNamespace(ctx context.Context, namespace string, r io.Reader) error {
+	reg := o.cli.Registry()
+
+	type aggregateKey struct {
+		namespace string
+		name      string
+		id        uuid.UUID
+	}
+	var aggregates []aggregateKey
+	seen := map[aggregateKey]bool{}
+
+	err := o.work(ctx, func(ctx context.Context, unit es.Unit) error {
+		var batch []*es.Event
+		flush := func() error {
+			if err := unit.Data().SaveEvents(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+			return nil
+		}
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var record rawRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				return err
+			}
+
+			if record.Event != nil {
+				evt := record.Event.Event
+				evt.Data = record.Event.Data
+				if namespace != "" {
+					evt.Namespace = namespace
+				}
+
+				key := aggregateKey{evt.Namespace, evt.AggregateType, evt.AggregateId}
+				if !seen[key] {
+					seen[key] = true
+					aggregates = append(aggregates, key)
+				}
+
+				batch = append(batch, evt)
+				if len(batch) >= batchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+
+			if record.Command != nil {
+				persisted := record.Command.PersistedCommand
+				cmdConfig, err := reg.GetCommandConfig(persisted.CommandType)
+				if err != nil {
+					return err
+				}
+				cmd, err := cmdConfig.Factory()
+				if err != nil {
+					return err
+				}
+				if err := json.Unmarshal(record.Command.Command, cmd); err != nil {
+					return err
+				}
+				// the dump may be restored next to the commands it came from.
+				persisted.Id = uuid.New()
+				persisted.LeasedBy = ""
+				persisted.LeaseExpiresAt = nil
+				persisted.Command = cmd
+				if namespace != "" {
+					persisted.Namespace = namespace
+				}
+				if persisted.CreatedAt.IsZero() {
+					persisted.CreatedAt = time.Now()
+				}
+				if err := unit.Data().CreatePersistedCommand(ctx, persisted); err != nil {
+					return err
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range aggregates {
+		if err := o.Replay(ctx, key.name, key.namespace, key.id); err != nil && !errors.Is(err, es.ErrHandlerNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewOperator(cli es.Client) *Operator {
+	return &Operator{
+		cli: cli,
+	}
+}
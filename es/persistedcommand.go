@@ -1,9 +1,13 @@
 package es
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type PersistedCommand struct {
@@ -14,4 +18,96 @@ type PersistedCommand struct {
 	ExecuteAfter time.Time `json:"execute_after" required:"true"`
 	CreatedAt    time.Time `json:"created_at" required:"true"`
 	By           *Actor    `json:"by"`
+
+	// Recurrence is a cron expression or interval, recurring commands
+	// are rescheduled after every successful run.
+	Recurrence string `json:"recurrence,omitempty"`
+
+	// Metadata, Trace and ScheduledBy restore the context the command
+	// was scheduled in when it runs.
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Trace       map[string]string      `json:"trace,omitempty"`
+	ScheduledBy *ScheduledBy           `json:"scheduled_by,omitempty"`
+
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+
+	// LeasedBy is the scheduler running the command until LeaseExpiresAt,
+	// after which any scheduler may reclaim it.
+	LeasedBy       string     `json:"leased_by,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+}
+
+// ScheduledBy is the event being handled when the command was scheduled.
+type ScheduledBy struct {
+	Service       string    `json:"service"`
+	Namespace     string    `json:"namespace"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateId   uuid.UUID `json:"aggregate_id"`
+	Version       int       `json:"version"`
+	Type          string    `json:"type"`
+}
+
+func newScheduledBy(evt *Event) *ScheduledBy {
+	if evt == nil {
+		return nil
+	}
+	return &ScheduledBy{
+		Service:       evt.Service,
+		Namespace:     evt.Namespace,
+		AggregateType: evt.AggregateType,
+		AggregateId:   evt.AggregateId,
+		Version:       evt.Version,
+		Type:          evt.Type,
+	}
+}
+
+var scheduledIdSpace = uuid.MustParse("0d4b6c4e-6c3f-4c55-9a43-51a4bd0f5c62")
+
+// scheduledId is derived from the event being handled so a redelivered event
+// schedules the same command again instead of a copy. The execute time is part
+// of it, handlers schedule from GetTime(ctx) which is the same on redelivery.
+func scheduledId(by *ScheduledBy, commandType string, cmd Command, executeAfter time.Time) (uuid.UUID, error) {
+	if by == nil {
+		return uuid.New(), nil
+	}
+
+	raw, err := json.Marshal(cmd)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	name := fmt.Sprintf("%s/%s/%s/%s/%d/%s/%s/%d/%s", by.Service, by.Namespace, by.AggregateType, by.AggregateId, by.Version, by.Type, commandType, executeAfter.UnixNano(), raw)
+	return uuid.NewSHA1(scheduledIdSpace, []byte(name)), nil
+}
+
+// restore returns the context the command was scheduled in.
+func (p *PersistedCommand) restore(ctx context.Context) context.Context {
+	ctx = SetNamespace(ctx, p.Namespace)
+	ctx = SetActor(ctx, p.By)
+	if len(p.Metadata) > 0 {
+		ctx = SetMetadata(ctx, p.Metadata)
+	}
+	if len(p.Trace) > 0 {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier(p.Trace))
+	}
+	return ctx
+}
+
+// Backoff returns how long to wait before retrying after the given attempt.
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff doubles the wait after every attempt up to max.
+func ExponentialBackoff(base time.Duration, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		wait := base
+		for i := 1; i < attempt && wait < max; i++ {
+			wait *= 2
+		}
+		if wait > max {
+			return max
+		}
+		return wait
+	}
 }
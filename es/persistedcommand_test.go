@@ -0,0 +1,101 @@
+package es
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_ExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, e := range expected {
+		if got := backoff(i + 1); got != e {
+			t.Errorf("attempt %d: expected %s, got %s", i+1, e, got)
+		}
+	}
+}
+
+func Test_ScheduledId(t *testing.T) {
+	cmd := &BaseCommand{AggregateId: uuid.New()}
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	random, err := scheduledId(nil, "BaseCommand", cmd, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if random == uuid.Nil {
+		t.Error("expected an id without an event")
+	}
+
+	by := newScheduledBy(&Event{Service: "users", Namespace: "default", AggregateType: "User", AggregateId: uuid.New(), Version: 3, Type: "UserCreated"})
+	first, err := scheduledId(by, "BaseCommand", cmd, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := scheduledId(by, "BaseCommand", cmd, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expected the same id for the same event, got %s and %s", first, second)
+	}
+
+	other, err := scheduledId(by, "BaseCommand", &BaseCommand{AggregateId: uuid.New()}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == other {
+		t.Error("expected a different id for a different command")
+	}
+
+	later, err := scheduledId(by, "BaseCommand", cmd, at.Add(7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == later {
+		t.Error("expected a different id for the same command at another time")
+	}
+}
+
+func Test_PersistedCommandRestore(t *testing.T) {
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(parent, carrier)
+
+	actor := &Actor{Id: uuid.New(), Type: "user"}
+	persisted := &PersistedCommand{
+		Namespace: "tenant",
+		By:        actor,
+		Metadata:  map[string]interface{}{"correlation_id": "abc"},
+		Trace:     carrier,
+	}
+
+	ctx := persisted.restore(context.Background())
+	if ns := GetNamespace(ctx); ns != "tenant" {
+		t.Errorf("expected namespace tenant, got %s", ns)
+	}
+	if GetActor(ctx) != actor {
+		t.Error("expected the actor to be restored")
+	}
+
+	metadata := GetMetadata(ctx)
+	if metadata["correlation_id"] != "abc" {
+		t.Errorf("expected metadata to be restored, got %v", metadata)
+	}
+	if metadata["span.trace_id"] != traceId.String() {
+		t.Errorf("expected trace %s, got %v", traceId, metadata["span.trace_id"])
+	}
+}
@@ -0,0 +1,106 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-apis/eventsourcing/es/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AuthorizeRequest is what a policy decides on. Aggregate is nil for
+// commands that are not handled by an aggregate.
+type AuthorizeRequest struct {
+	Actor     *Actor
+	Namespace string
+	Command   Command
+	Aggregate Entity
+}
+
+// Policy decides if the actor is allowed to run a command.
+type Policy interface {
+	Allow(ctx context.Context, req *AuthorizeRequest) (bool, error)
+}
+
+// PolicyFunc is a function that can be used as a policy.
+type PolicyFunc func(ctx context.Context, req *AuthorizeRequest) (bool, error)
+
+// Allow implements Policy.
+func (f PolicyFunc) Allow(ctx context.Context, req *AuthorizeRequest) (bool, error) {
+	return f(ctx, req)
+}
+
+// AuthorizedCommand can be implemented by commands that carry their own policy.
+type AuthorizedCommand interface {
+	Command
+
+	Allow(ctx context.Context, req *AuthorizeRequest) (bool, error)
+}
+
+// CommandPolicy registers a policy for a command type.
+type CommandPolicy struct {
+	Command Command
+	Policy  Policy
+}
+
+func NewCommandPolicy(cmd Command, p Policy) *CommandPolicy {
+	return &CommandPolicy{
+		Command: cmd,
+		Policy:  p,
+	}
+}
+
+// Policies by lowered command name.
+type Policies map[string][]Policy
+
+func (p Policies) Add(cp *CommandPolicy) {
+	name := strings.ToLower(utils.GetTypeName(cp.Command))
+	p[name] = append(p[name], cp.Policy)
+}
+
+// Authorize runs every policy for the command and returns ErrForbidden on the first denial.
+func (p Policies) Authorize(ctx context.Context, cmd Command, aggregate Entity) error {
+	name := utils.GetTypeName(cmd)
+
+	policies := append([]Policy{}, p[strings.ToLower(name)]...)
+	if ac, ok := cmd.(AuthorizedCommand); ok {
+		policies = append(policies, PolicyFunc(ac.Allow))
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	req := &AuthorizeRequest{
+		Actor:     GetActor(ctx),
+		Namespace: GetNamespace(ctx),
+		Command:   cmd,
+		Aggregate: aggregate,
+	}
+	for _, policy := range policies {
+		ok, err := policy.Allow(ctx, req)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			attrs := []attribute.KeyValue{
+				attribute.String("command", name),
+				attribute.String("namespace", req.Namespace),
+			}
+			actor := "anonymous"
+			if req.Actor != nil {
+				attrs = append(attrs,
+					attribute.String("actor.id", req.Actor.Id.String()),
+					attribute.String("actor.type", req.Actor.Type),
+				)
+				actor = req.Actor.Type + "/" + req.Actor.Id.String()
+			}
+			trace.SpanFromContext(ctx).AddEvent("denied", trace.WithAttributes(attrs...))
+			log.Printf("denied command %s on %s in namespace %s for actor %s", name, cmd.GetAggregateId(), req.Namespace, actor)
+			return fmt.Errorf("%s on %s: %w", name, cmd.GetAggregateId(), ErrForbidden)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type policyCommand struct {
+	BaseCommand
+}
+
+type ownedCommand struct {
+	BaseCommand
+}
+
+func (c *ownedCommand) Allow(ctx context.Context, req *AuthorizeRequest) (bool, error) {
+	return req.Actor != nil && req.Actor.Id == c.AggregateId, nil
+}
+
+func Test_Policies(t *testing.T) {
+	admin := uuid.New()
+	policies := Policies{}
+	policies.Add(NewCommandPolicy(&policyCommand{}, PolicyFunc(func(ctx context.Context, req *AuthorizeRequest) (bool, error) {
+		return req.Actor != nil && req.Actor.Id == admin && req.Namespace == "tenant", nil
+	})))
+
+	ctx := SetNamespace(context.Background(), "tenant")
+	adminCtx := SetActor(ctx, &Actor{Id: admin, Type: "user"})
+	userCtx := SetActor(ctx, &Actor{Id: uuid.New(), Type: "user"})
+
+	if err := policies.Authorize(adminCtx, &policyCommand{}, nil); err != nil {
+		t.Errorf("expected admin to be allowed, got %v", err)
+	}
+	if err := policies.Authorize(userCtx, &policyCommand{}, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+
+	owned := &ownedCommand{BaseCommand: BaseCommand{AggregateId: admin}}
+	if err := policies.Authorize(adminCtx, owned, nil); err != nil {
+		t.Errorf("expected owner to be allowed, got %v", err)
+	}
+	if err := policies.Authorize(userCtx, owned, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+}
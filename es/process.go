@@ -0,0 +1,134 @@
+package es
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IsProcess interface {
+	Process()
+}
+
+// Process is a saga with state, loaded and saved by the
+// correlation id of each event it handles.
+type Process interface {
+	IsProcess
+	Entity
+
+	IsCompleted() bool
+}
+
+// ProcessCorrelator finds the process instance for an event,
+// processes without it are correlated by the aggregate id.
+type ProcessCorrelator interface {
+	Correlate(ctx context.Context, evt *Event) (uuid.UUID, error)
+}
+
+// ProcessTimeout is delivered to a process when a timeout it requested expires.
+type ProcessTimeout struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+var processTimeoutType = reflect.TypeOf(&ProcessTimeout{})
+
+// DeliverProcessTimeout is scheduled by a process to deliver its timeout.
+type DeliverProcessTimeout struct {
+	BaseCommand
+
+	Process string `json:"process" required:"true"`
+	Name    string `json:"name" required:"true"`
+}
+
+// ProcessTimeouts holds the scheduled command of each pending timeout by name.
+type ProcessTimeouts map[string]uuid.UUID
+
+func (t ProcessTimeouts) GormDataType() string {
+	return "json"
+}
+
+func (t ProcessTimeouts) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (t *ProcessTimeouts) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("unsupported process timeouts: %T", value)
+	}
+}
+
+type timeoutRequest struct {
+	name   string
+	after  time.Duration
+	cancel bool
+}
+
+type BaseProcess struct {
+	BaseAggregate
+
+	// Version guards against two units handling events for the same
+	// process at once, the second save fails with ErrConcurrency.
+	Version   int             `json:"version"`
+	Completed bool            `json:"completed"`
+	Timeouts  ProcessTimeouts `json:"timeouts"`
+
+	requests []timeoutRequest
+}
+
+func (p *BaseProcess) Process() {
+}
+
+func (p *BaseProcess) GetVersion() int {
+	return p.Version
+}
+
+func (p *BaseProcess) SetVersion(version int) {
+	p.Version = version
+}
+
+// RequestTimeout delivers a ProcessTimeout with the name after the duration,
+// replacing a pending timeout with the same name.
+func (p *BaseProcess) RequestTimeout(name string, after time.Duration) {
+	p.requests = append(p.requests, timeoutRequest{name: name, after: after})
+}
+
+// CancelTimeout cancels the pending timeout with the name.
+func (p *BaseProcess) CancelTimeout(name string) {
+	p.requests = append(p.requests, timeoutRequest{name: name, cancel: true})
+}
+
+func (p *BaseProcess) base() *BaseProcess {
+	return p
+}
+
+// Complete the process, events for it are ignored from now on
+// and pending timeouts are cancelled.
+func (p *BaseProcess) Complete() {
+	p.Completed = true
+}
+
+// IsCompleted returns whether the process has completed.
+func (p *BaseProcess) IsCompleted() bool {
+	return p.Completed
+}
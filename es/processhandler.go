@@ -0,0 +1,194 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// processBase gives the handler access to the embedded BaseProcess.
+type processBase interface {
+	base() *BaseProcess
+}
+
+type processEventHandler struct {
+	service      string
+	entityConfig *EntityConfig
+	handles      SagaHandles
+}
+
+func (b *processEventHandler) load(ctx context.Context, id uuid.UUID) (Process, error) {
+	unit, err := GetUnit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := unit.Load(ctx, b.entityConfig.Name, id)
+	if err != nil {
+		return nil, err
+	}
+	process, ok := loaded.(Process)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a process", b.entityConfig.Name)
+	}
+	return process, nil
+}
+
+func (b *processEventHandler) correlate(ctx context.Context, evt *Event) (uuid.UUID, error) {
+	entity, err := b.entityConfig.Factory()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if c, ok := entity.(ProcessCorrelator); ok {
+		return c.Correlate(ctx, evt)
+	}
+	return evt.AggregateId, nil
+}
+
+func (b *processEventHandler) HandleEvent(ctx context.Context, evt *Event) error {
+	ctx = SetNamespace(ctx, evt.Namespace)
+
+	id, err := b.correlate(ctx, evt)
+	if err != nil {
+		return err
+	}
+	process, err := b.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	return b.handle(ctx, process, evt)
+}
+
+// HandleTimeout delivers the timeout as a ProcessTimeout event.
+func (b *processEventHandler) HandleTimeout(ctx context.Context, cmd *DeliverProcessTimeout) error {
+	process, err := b.load(ctx, cmd.AggregateId)
+	if err != nil {
+		return err
+	}
+
+	// the timeout was cancelled or replaced after it was scheduled.
+	var id uuid.UUID
+	if pb, ok := process.(processBase); ok {
+		pending, ok := pb.base().Timeouts[cmd.Name]
+		if !ok {
+			return nil
+		}
+		id = pending
+		delete(pb.base().Timeouts, cmd.Name)
+	}
+
+	evt := &Event{
+		Service:       b.service,
+		Namespace:     GetNamespace(ctx),
+		AggregateId:   cmd.AggregateId,
+		AggregateType: b.entityConfig.Name,
+		Type:          "ProcessTimeout",
+		By:            GetActor(ctx),
+		Timestamp:     GetTime(ctx),
+		Data:          &ProcessTimeout{Id: id, Name: cmd.Name},
+		Metadata:      GetMetadata(ctx),
+	}
+	return b.handle(ctx, process, evt)
+}
+
+func (b *processEventHandler) handle(ctx context.Context, process Process, evt *Event) error {
+	unit, err := GetUnit(ctx)
+	if err != nil {
+		return err
+	}
+	if process.IsCompleted() {
+		return nil
+	}
+
+	cmds, err := b.handles.Handle(process, ctx, evt)
+	if err != nil {
+		return err
+	}
+
+	if pb, ok := process.(processBase); ok {
+		if err := b.timeouts(ctx, unit, pb.base()); err != nil {
+			return err
+		}
+	}
+
+	if err := unit.Save(ctx, b.entityConfig.Name, process); err != nil {
+		return err
+	}
+	return dispatchSteps(ctx, b.service, b.entityConfig.Name, evt, cmds)
+}
+
+// timeouts schedules and cancels the timeouts requested while handling.
+// Processes without a ProcessTimeout handle have nothing to deliver a timeout
+// to, so their requests are dropped.
+func (b *processEventHandler) timeouts(ctx context.Context, unit Unit, p *BaseProcess) error {
+	requests := p.requests
+	p.requests = nil
+	if _, ok := b.handles[processTimeoutType]; !ok {
+		requests = nil
+	}
+
+	if p.Completed {
+		requests = nil
+		for name := range p.Timeouts {
+			requests = append(requests, timeoutRequest{name: name, cancel: true})
+		}
+	}
+
+	scheduler := unit.Scheduler()
+	for _, r := range requests {
+		if id, ok := p.Timeouts[r.name]; ok {
+			if err := scheduler.Cancel(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			delete(p.Timeouts, r.name)
+		}
+		if r.cancel {
+			continue
+		}
+
+		id, err := scheduler.Schedule(ctx, &DeliverProcessTimeout{
+			BaseCommand: BaseCommand{AggregateId: p.Id},
+			Process:     b.entityConfig.Name,
+			Name:        r.name,
+		}, GetTime(ctx).Add(r.after))
+		if err != nil {
+			return err
+		}
+		if p.Timeouts == nil {
+			p.Timeouts = ProcessTimeouts{}
+		}
+		p.Timeouts[r.name] = id
+	}
+	return nil
+}
+
+func NewProcessEventHandler(service string, entityConfig *EntityConfig, handles SagaHandles) EventHandler {
+	return newProcessEventHandler(service, entityConfig, handles)
+}
+
+func newProcessEventHandler(service string, entityConfig *EntityConfig, handles SagaHandles) *processEventHandler {
+	return &processEventHandler{
+		service:      service,
+		entityConfig: entityConfig,
+		handles:      handles,
+	}
+}
+
+// processTimeoutHandler routes timeouts to the process that requested them.
+type processTimeoutHandler struct {
+	processes map[string]*processEventHandler
+}
+
+func (h *processTimeoutHandler) HandleCommand(ctx context.Context, cmd Command) error {
+	timeout, ok := cmd.(*DeliverProcessTimeout)
+	if !ok {
+		return fmt.Errorf("unexpected command %T", cmd)
+	}
+	process, ok := h.processes[timeout.Process]
+	if !ok {
+		return fmt.Errorf("process %s: %w", timeout.Process, ErrHandlerNotFound)
+	}
+	return process.HandleTimeout(ctx, timeout)
+}
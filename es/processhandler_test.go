@@ -0,0 +1,75 @@
+package es
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type timeoutScheduler struct {
+	Scheduler
+
+	scheduled map[string]time.Time
+}
+
+func (s *timeoutScheduler) Schedule(ctx context.Context, cmd Command, executeAfter time.Time) (uuid.UUID, error) {
+	s.scheduled[cmd.(*DeliverProcessTimeout).Name] = executeAfter
+	return uuid.New(), nil
+}
+
+type timeoutUnit struct {
+	Unit
+
+	scheduler *timeoutScheduler
+}
+
+func (u *timeoutUnit) Scheduler() Scheduler {
+	return u.scheduler
+}
+
+func Test_ProcessTimeouts(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	ctx := SetTime(context.Background(), now)
+
+	tests := []struct {
+		name      string
+		handles   SagaHandles
+		scheduled bool
+	}{
+		{"with a timeout handle", SagaHandles{processTimeoutType: &SagaHandle{}}, true},
+		{"without a timeout handle", SagaHandles{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := &timeoutUnit{scheduler: &timeoutScheduler{scheduled: map[string]time.Time{}}}
+			h := newProcessEventHandler("test", &EntityConfig{Name: "Onboarding"}, tt.handles)
+
+			p := &BaseProcess{}
+			p.RequestTimeout("reminder", time.Hour)
+			if err := h.timeouts(ctx, unit, p); err != nil {
+				t.Fatal(err)
+			}
+
+			at, ok := unit.scheduler.scheduled["reminder"]
+			if ok != tt.scheduled {
+				t.Fatalf("expected scheduled %v, got %v", tt.scheduled, ok)
+			}
+			if !tt.scheduled {
+				if len(p.Timeouts) != 0 {
+					t.Errorf("expected no pending timeouts, got %v", p.Timeouts)
+				}
+				return
+			}
+			// the time comes from the context so replays schedule the same.
+			if !at.Equal(now.Add(time.Hour)) {
+				t.Errorf("expected %s, got %s", now.Add(time.Hour), at)
+			}
+			if _, ok := p.Timeouts["reminder"]; !ok {
+				t.Errorf("expected the timeout to be pending")
+			}
+		})
+	}
+}
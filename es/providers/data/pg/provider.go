@@ -8,6 +8,7 @@ import (
 	"github.com/go-apis/eventsourcing/es/internal/gdb"
 	"github.com/go-apis/utils/xgorm"
 	"github.com/go-apis/utils/xlog"
+	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 )
 
@@ -27,6 +28,16 @@ func New(ctx context.Context, cfg *es.ProviderConfig, reg es.Registry) (es.Conn,
 		xgorm.WithDisableNestedTransaction(),
 		xgorm.WithSkipDefaultTransaction(),
 	}
+
+	var replicas []*gorm.DB
+	for _, replicaCfg := range cfg.Data.Replicas {
+		replica, err := xgorm.NewDb(ctx, replicaCfg, dbops...)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+
 	if cfg.Data.Reset {
 		dbops = append(dbops, xgorm.WithRecreate())
 	}
@@ -40,7 +51,7 @@ func New(ctx context.Context, cfg *es.ProviderConfig, reg es.Registry) (es.Conn,
 		return nil, err
 	}
 
-	return gdb.NewConn(ctx, cfg.Service, db, reg, false)
+	return gdb.NewConn(ctx, cfg.Service, db, reg, false, replicas...)
 }
 
 func init() {
@@ -31,6 +31,21 @@ func New(ctx context.Context, cfg *es.ProviderConfig, reg es.Registry) (es.Conn,
 		return nil, err
 	}
 
+	// every connection to :memory: is a new empty database, so the stream
+	// handlers and the scheduler would see no tables on a second one. Keeping
+	// to one serializes units, a unit must never wait on another while it
+	// holds a transaction.
+	if cfg.Data.Sqlite.Memory {
+		sqlDb, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDb.SetMaxOpenConns(1)
+		sqlDb.SetMaxIdleConns(1)
+		sqlDb.SetConnMaxIdleTime(0)
+		sqlDb.SetConnMaxLifetime(0)
+	}
+
 	if err := gdb.AutoMigrate(ctx, db, cfg.Service, reg); err != nil {
 		return nil, err
 	}
@@ -24,8 +24,10 @@ type Pagination[T any] struct {
 type Query[T Entity] interface {
 	Get(ctx context.Context, id uuid.UUID) (T, error)
 	Find(ctx context.Context, filter Filter) ([]T, error)
+	FindInto(ctx context.Context, filter Filter, out interface{}) error
 	Count(ctx context.Context, filter Filter) (int, error)
 	Pagination(ctx context.Context, filter Filter) (*Pagination[T], error)
+	Aggregate(ctx context.Context, filter Filter, groupBy []GroupBy, measures []Measure) ([]AggregateRow, error)
 }
 
 type query[T Entity] struct {
@@ -43,9 +45,24 @@ func (q *query[T]) getNamespace(ctx context.Context) string {
 	return ""
 }
 
+func (q *query[T]) getContext(ctx context.Context) context.Context {
+	if q.options.PrimaryOnly {
+		return SetPrimaryOnly(ctx)
+	}
+	return ctx
+}
+
+func (q *query[T]) getFilter(filter Filter) Filter {
+	if len(filter.Select) == 0 && len(q.options.Select) > 0 {
+		filter.Select = q.options.Select
+	}
+	return filter
+}
+
 func (q *query[T]) Get(ctx context.Context, id uuid.UUID) (T, error) {
 	pctx, pspan := otel.Tracer("Query").Start(ctx, "Get")
 	defer pspan.End()
+	pctx = q.getContext(pctx)
 
 	var item T
 	unit, err := GetUnit(pctx)
@@ -63,6 +80,7 @@ func (q *query[T]) Get(ctx context.Context, id uuid.UUID) (T, error) {
 func (q *query[T]) Find(ctx context.Context, filter Filter) ([]T, error) {
 	pctx, pspan := otel.Tracer("Query").Start(ctx, "Find")
 	defer pspan.End()
+	pctx = q.getContext(pctx)
 
 	unit, err := GetUnit(pctx)
 	if err != nil {
@@ -72,15 +90,30 @@ func (q *query[T]) Find(ctx context.Context, filter Filter) ([]T, error) {
 	namespace := q.getNamespace(pctx)
 
 	var items []T
-	if err := unit.Find(pctx, q.name, namespace, filter, &items); err != nil {
+	if err := unit.Find(pctx, q.name, namespace, q.getFilter(filter), &items); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
+func (q *query[T]) FindInto(ctx context.Context, filter Filter, out interface{}) error {
+	pctx, pspan := otel.Tracer("Query").Start(ctx, "FindInto")
+	defer pspan.End()
+	pctx = q.getContext(pctx)
+
+	unit, err := GetUnit(pctx)
+	if err != nil {
+		return err
+	}
+
+	namespace := q.getNamespace(pctx)
+	return unit.Find(pctx, q.name, namespace, q.getFilter(filter), out)
+}
+
 func (q *query[T]) Count(ctx context.Context, filter Filter) (int, error) {
 	pctx, pspan := otel.Tracer("Query").Start(ctx, "Count")
 	defer pspan.End()
+	pctx = q.getContext(pctx)
 
 	unit, err := GetUnit(pctx)
 	if err != nil {
@@ -94,6 +127,7 @@ func (q *query[T]) Count(ctx context.Context, filter Filter) (int, error) {
 func (q *query[T]) Pagination(ctx context.Context, filter Filter) (*Pagination[T], error) {
 	pctx, pspan := otel.Tracer("Query").Start(ctx, "Pagination")
 	defer pspan.End()
+	pctx = q.getContext(pctx)
 
 	if filter.Limit == nil {
 		return nil, fmt.Errorf("Limit required for pagination")
@@ -114,7 +148,7 @@ func (q *query[T]) Pagination(ctx context.Context, filter Filter) (*Pagination[T
 	}
 
 	var items []T
-	if err := unit.Find(pctx, q.name, namespace, filter, &items); err != nil {
+	if err := unit.Find(pctx, q.name, namespace, q.getFilter(filter), &items); err != nil {
 		return nil, err
 	}
 
@@ -128,6 +162,34 @@ func (q *query[T]) Pagination(ctx context.Context, filter Filter) (*Pagination[T
 	}, nil
 }
 
+func (q *query[T]) Aggregate(ctx context.Context, filter Filter, groupBy []GroupBy, measures []Measure) ([]AggregateRow, error) {
+	pctx, pspan := otel.Tracer("Query").Start(ctx, "Aggregate")
+	defer pspan.End()
+	pctx = q.getContext(pctx)
+
+	if len(measures) == 0 {
+		return nil, fmt.Errorf("At least one measure required for aggregate")
+	}
+
+	unit, err := GetUnit(pctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := q.getNamespace(pctx)
+	return unit.Aggregate(pctx, q.name, namespace, filter, groupBy, measures)
+}
+
+// FindAs runs the query but only loads the columns of R, which is
+// usually a lighter view of T used for list endpoints.
+func FindAs[R any, T Entity](ctx context.Context, q Query[T], filter Filter) ([]R, error) {
+	var items []R
+	if err := q.FindInto(ctx, filter, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func NewQuery[T Entity](options ...QueryOption) Query[T] {
 	var entity T
 	opts := NewEntityOptions(entity)
@@ -3,6 +3,8 @@ package es
 type QueryOptions struct {
 	UseNamespace bool
 	Namespace    string
+	Select       []string
+	PrimaryOnly  bool
 }
 
 type QueryOption func(*QueryOptions)
@@ -19,6 +21,20 @@ func WithNamespace(namespace string) QueryOption {
 	}
 }
 
+// WithSelect limits the columns fetched when the filter doesn't select any.
+func WithSelect(columns ...string) QueryOption {
+	return func(opts *QueryOptions) {
+		opts.Select = columns
+	}
+}
+
+// WithPrimaryOnly skips the read replicas so the query sees its own writes.
+func WithPrimaryOnly() QueryOption {
+	return func(opts *QueryOptions) {
+		opts.PrimaryOnly = true
+	}
+}
+
 func DefaultQueryOptions() *QueryOptions {
 	return &QueryOptions{
 		UseNamespace: true,
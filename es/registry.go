@@ -19,15 +19,23 @@ type registry struct {
 
 func NewRegistry(service string, items ...interface{}) (Registry, error) {
 	var sagas []IsSaga
+	var processes []IsProcess
 	var projectors []IsProjector
 	var eventHandlers []IsEventHandler
 	var commandHandlers []IsCommandHandler
 	var aggregates []Aggregate
 	var entities []Entity
 	var events []interface{}
+	var commandMiddlewares []CommandMiddleware
+	var eventMiddlewares []*GroupEventMiddleware
+	var retryPolicies []*RetryPolicy
+	policies := Policies{}
 
 	for _, item := range items {
 		switch raw := item.(type) {
+		case IsProcess:
+			processes = append(processes, raw)
+			continue
 		case IsSaga:
 			sagas = append(sagas, raw)
 			continue
@@ -40,6 +48,27 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 		case IsCommandHandler:
 			commandHandlers = append(commandHandlers, raw)
 			continue
+		case CommandMiddleware:
+			commandMiddlewares = append(commandMiddlewares, raw)
+			continue
+		case func(CommandHandler) CommandHandler:
+			commandMiddlewares = append(commandMiddlewares, raw)
+			continue
+		case EventMiddleware:
+			eventMiddlewares = append(eventMiddlewares, NewGroupEventMiddleware("", raw))
+			continue
+		case func(EventHandler) EventHandler:
+			eventMiddlewares = append(eventMiddlewares, NewGroupEventMiddleware("", raw))
+			continue
+		case *GroupEventMiddleware:
+			eventMiddlewares = append(eventMiddlewares, raw)
+			continue
+		case *CommandPolicy:
+			policies.Add(raw)
+			continue
+		case *RetryPolicy:
+			retryPolicies = append(retryPolicies, raw)
+			continue
 		case IsEvent:
 			events = append(events, raw)
 		case Aggregate:
@@ -57,6 +86,14 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 	commandRegistry := NewCommandRegistry()
 	eventRegistry := NewEventRegistry()
 
+	commandRegistry.AddCommandMiddleware(commandMiddlewares...)
+	for _, mw := range eventMiddlewares {
+		eventRegistry.AddEventMiddleware(mw.Group, mw.Middleware)
+	}
+	for _, policy := range retryPolicies {
+		eventRegistry.SetRetryPolicy(policy)
+	}
+
 	// register entities
 	for _, entity := range entities {
 		opts := NewEntityOptions(entity)
@@ -69,6 +106,11 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 		}
 	}
 
+	// compensation records
+	if len(sagas) > 0 || len(processes) > 0 {
+		events = append(events, &SagaCompensated{})
+	}
+
 	// events
 	for _, evt := range events {
 		evtConfig := NewEventConfig(service, evt)
@@ -86,7 +128,7 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 		}
 		// handles!
 		commandHandles := NewCommandHandles(agg)
-		h := NewAggregateHandler(entityConfig, commandHandles)
+		h := NewAggregateHandler(entityConfig, commandHandles, policies)
 
 		if err := entityRegistry.AddEntity(entityConfig); err != nil {
 			return nil, err
@@ -106,7 +148,7 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 	// handlers
 	for _, commandHandler := range commandHandlers {
 		commandHandles := NewCommandHandles(commandHandler)
-		h := NewCommandHandler(commandHandler, commandHandles)
+		h := NewCommandHandler(commandHandler, commandHandles, policies)
 
 		for t := range commandHandles {
 			commandConfig := NewCommandConfig(t)
@@ -120,15 +162,51 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 	for _, saga := range sagas {
 		eventHandlerConfig := NewEventHandlerConfig(saga)
 		handles := NewSagaHandles(saga)
-		h := NewSagaEventHandler(handles, saga)
+		h := NewSagaEventHandler(service, handles, saga)
+
+		for t := range handles {
+			eventConfig := NewEventConfig(service, t)
+			if err := eventRegistry.AddGroupEventHandler(h, eventHandlerConfig.Group, eventConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// processes
+	timeoutHandler := &processTimeoutHandler{processes: map[string]*processEventHandler{}}
+	for _, process := range processes {
+		opts := NewEntityOptions(process)
+		entityConfig, err := NewEntityConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := entityRegistry.AddEntity(entityConfig); err != nil {
+			return nil, err
+		}
+
+		eventHandlerConfig := NewEventHandlerConfig(process)
+		handles := NewProcessHandles(process)
+		h := newProcessEventHandler(service, entityConfig, handles)
+		timeoutHandler.processes[entityConfig.Name] = h
 
 		for t := range handles {
+			// timeouts are delivered by the scheduler, not the event bus.
+			if t == processTimeoutType {
+				continue
+			}
 			eventConfig := NewEventConfig(service, t)
 			if err := eventRegistry.AddGroupEventHandler(h, eventHandlerConfig.Group, eventConfig); err != nil {
 				return nil, err
 			}
 		}
 	}
+	if len(processes) > 0 {
+		commandConfig := NewCommandConfig(&DeliverProcessTimeout{})
+		commandConfig.Internal = true
+		if err := commandRegistry.SetCommandHandler(timeoutHandler, commandConfig); err != nil {
+			return nil, err
+		}
+	}
 
 	// projectors
 	for _, projector := range projectors {
@@ -156,6 +234,9 @@ func NewRegistry(service string, items ...interface{}) (Registry, error) {
 				if err := eventRegistry.AddGroupEventHandler(h, eventHandlerConfig.Group, eventConfig); err != nil {
 					return nil, err
 				}
+				if err := eventRegistry.AddProjectionEventHandler(h, entityConfig.Name, eventConfig); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
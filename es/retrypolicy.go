@@ -0,0 +1,87 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// handlers retry in process, so they back off for seconds rather than hours.
+var defaultRetryBackoff = ExponentialBackoff(100*time.Millisecond, 10*time.Second)
+
+// RetryPolicy decides how often a group's handlers retry a message
+// before it's moved to the dead letters.
+type RetryPolicy struct {
+	Group       string
+	MaxAttempts int
+	Backoff     Backoff
+
+	// Retryable reports whether an error is worth another attempt,
+	// every error is when nil.
+	Retryable func(err error) bool
+}
+
+// RetryOn retries only errors matching one of errs.
+func RetryOn(errs ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, e := range errs {
+			if errors.Is(err, e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Run calls fn until it succeeds, returns an error that isn't retryable or
+// runs out of attempts. It returns the number of attempts made.
+func (p *RetryPolicy) Run(ctx context.Context, fn func(ctx context.Context) error) (int, error) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = defaultRetryBackoff
+	}
+
+	attempt := 0
+	for {
+		attempt++
+		err := fn(ctx)
+		if err == nil {
+			return attempt, nil
+		}
+		if attempt >= maxAttempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return attempt, err
+		}
+
+		timer := time.NewTimer(backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func NewRetryPolicy(group string, maxAttempts int, backoff Backoff) *RetryPolicy {
+	return &RetryPolicy{
+		Group:       group,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+	}
+}
+
+// DeadLetter is a message a group's handlers gave up on.
+type DeadLetter struct {
+	Id        uuid.UUID `json:"id"`
+	Group     string    `json:"group"`
+	Payload   []byte    `json:"payload"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,134 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_RetryPolicy(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"recovers", []error{errTemporary, errTemporary, nil}, 3, nil},
+		{"gives up", []error{errTemporary, errTemporary, errTemporary, errTemporary}, 3, errTemporary},
+		{"not retryable", []error{errPermanent, nil}, 1, errPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewRetryPolicy("group", 3, func(int) time.Duration { return time.Millisecond })
+			policy.Retryable = RetryOn(errTemporary)
+
+			calls := 0
+			attempts, err := policy.Run(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if attempts != tt.attempts || calls != tt.attempts {
+				t.Errorf("expected %d attempts, got %d (%d calls)", tt.attempts, attempts, calls)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+type retryData struct {
+	Data
+
+	deadLetters []*DeadLetter
+}
+
+func (d *retryData) SaveDeadLetter(ctx context.Context, deadLetter *DeadLetter) error {
+	d.deadLetters = append(d.deadLetters, deadLetter)
+	return nil
+}
+
+type retryConn struct {
+	data *retryData
+}
+
+func (c *retryConn) NewData(ctx context.Context) (Data, error) {
+	return c.data, nil
+}
+
+func (c *retryConn) Close(ctx context.Context) error {
+	return nil
+}
+
+func Test_ClientRetry(t *testing.T) {
+	errTemporary := errors.New("temporary")
+
+	tests := []struct {
+		name        string
+		fails       int
+		cancel      bool
+		calls       int
+		err         error
+		deadLetters int
+	}{
+		{"recovers", 2, false, 3, nil, 0},
+		{"gives up", 5, false, 3, nil, 1},
+		{"cancelled", 5, true, 1, context.Canceled, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &retryData{}
+			c := &client{
+				providerConfig: &ProviderConfig{Service: "test"},
+				conn:           &retryConn{data: data},
+				cache:          NewAggregateCache(),
+			}
+			policy := NewRetryPolicy("group", 3, func(int) time.Duration { return time.Millisecond })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			handler := c.retry("group", policy, func(ctx context.Context, payload []byte) error {
+				calls++
+				if tt.cancel {
+					cancel()
+				}
+				if calls <= tt.fails {
+					return errTemporary
+				}
+				return nil
+			})
+
+			err := handler(ctx, []byte(`{"type":"Tested"}`))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if calls != tt.calls {
+				t.Errorf("expected %d calls, got %d", tt.calls, calls)
+			}
+			if len(data.deadLetters) != tt.deadLetters {
+				t.Fatalf("expected %d dead letters, got %d", tt.deadLetters, len(data.deadLetters))
+			}
+			if tt.deadLetters == 0 {
+				return
+			}
+
+			deadLetter := data.deadLetters[0]
+			if deadLetter.Group != "group" || deadLetter.Attempts != 3 || deadLetter.Error != errTemporary.Error() {
+				t.Errorf("unexpected dead letter %+v", deadLetter)
+			}
+			if string(deadLetter.Payload) != `{"type":"Tested"}` {
+				t.Errorf("unexpected payload %s", deadLetter.Payload)
+			}
+		})
+	}
+}
@@ -85,7 +85,15 @@ func (h SagaHandles) Handle(agg interface{}, ctx context.Context, evt *Event) ([
 }
 
 func NewSagaHandles(s IsSaga) SagaHandles {
-	t := reflect.TypeOf(s)
+	return newSagaHandles(reflect.TypeOf(s))
+}
+
+// NewProcessHandles finds the handles of a process, they have the same shape as a saga's.
+func NewProcessHandles(p IsProcess) SagaHandles {
+	return newSagaHandles(reflect.TypeOf(p))
+}
+
+func newSagaHandles(t reflect.Type) SagaHandles {
 	handles := make(SagaHandles)
 	for i := 0; i < t.NumMethod(); i++ {
 		method := t.Method(i)
@@ -5,26 +5,24 @@ import (
 )
 
 type sagaEventHandler struct {
+	service string
+	name    string
 	handles SagaHandles
 	saga    IsSaga
 }
 
 func (b *sagaEventHandler) HandleEvent(ctx context.Context, evt *Event) error {
-	unit, err := GetUnit(ctx)
-	if err != nil {
-		return err
-	}
-
 	cmds, err := b.handles.Handle(b.saga, ctx, evt)
 	if err != nil {
 		return err
 	}
-
-	return unit.Dispatch(ctx, cmds...)
+	return dispatchSteps(ctx, b.service, b.name, evt, cmds)
 }
 
-func NewSagaEventHandler(handles SagaHandles, saga IsSaga) EventHandler {
+func NewSagaEventHandler(service string, handles SagaHandles, saga IsSaga) EventHandler {
 	return &sagaEventHandler{
+		service: service,
+		name:    NewEventHandlerConfig(saga).Name,
 		handles: handles,
 		saga:    saga,
 	}
@@ -0,0 +1,139 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-apis/eventsourcing/es/utils"
+	"github.com/google/uuid"
+)
+
+// Step is a saga command with the command that undoes it when a later step fails.
+type Step struct {
+	Command      Command
+	Compensation Command
+}
+
+// GetAggregateId of the step's command.
+func (s *Step) GetAggregateId() uuid.UUID {
+	return s.Command.GetAggregateId()
+}
+
+// NewStep returns a step, compensation can be nil when there's nothing to undo.
+func NewStep(cmd Command, compensation Command) *Step {
+	return &Step{
+		Command:      cmd,
+		Compensation: compensation,
+	}
+}
+
+// SagaCompensated records that a saga step failed and the earlier steps were compensated.
+type SagaCompensated struct {
+	Saga          string   `json:"saga"`
+	Step          int      `json:"step"`
+	Error         string   `json:"error"`
+	Compensations []string `json:"compensations"`
+}
+
+// eventRecorder saves events outside of an aggregate, they are published
+// with the rest of the unit's events.
+type eventRecorder interface {
+	record(ctx context.Context, events ...*Event) error
+}
+
+// dispatchSteps runs the saga commands one at a time when they include steps,
+// compensating the completed steps in reverse order if one fails.
+func dispatchSteps(ctx context.Context, service string, saga string, evt *Event, cmds []Command) error {
+	unit, err := GetUnit(ctx)
+	if err != nil {
+		return err
+	}
+
+	hasSteps := false
+	for _, cmd := range cmds {
+		if _, ok := cmd.(*Step); ok {
+			hasSteps = true
+			break
+		}
+	}
+	if !hasSteps {
+		_, err := unit.Dispatch(ctx, cmds...)
+		return err
+	}
+
+	var done []*Step
+	for i, cmd := range cmds {
+		step, ok := cmd.(*Step)
+		if !ok {
+			step = NewStep(cmd, nil)
+		}
+
+		if _, err := unit.Dispatch(ctx, step.Command); err != nil {
+			return compensate(ctx, unit, service, saga, evt, i, err, done)
+		}
+		done = append(done, step)
+	}
+	return nil
+}
+
+// compensate runs every compensation even when one fails, failures are
+// returned so the event is retried or dead lettered.
+func compensate(ctx context.Context, unit Unit, service string, saga string, evt *Event, failed int, cause error, done []*Step) error {
+	recorder, ok := unit.(eventRecorder)
+	if !ok {
+		return fmt.Errorf("saga %s: unit can't record compensations: %w", saga, cause)
+	}
+
+	compensated := &SagaCompensated{
+		Saga:          saga,
+		Step:          failed,
+		Error:         cause.Error(),
+		Compensations: []string{},
+	}
+
+	var errs []error
+	for i := len(done) - 1; i >= 0; i-- {
+		compensation := done[i].Compensation
+		if compensation == nil {
+			continue
+		}
+
+		name := utils.GetTypeName(compensation)
+		if _, err := unit.Dispatch(ctx, compensation); err != nil {
+			errs = append(errs, fmt.Errorf("compensation %s: %w", name, err))
+			continue
+		}
+		compensated.Compensations = append(compensated.Compensations, name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("saga %s step %d failed: %w", saga, failed, errors.Join(append([]error{cause}, errs...)...))
+	}
+
+	// one stream per handled event, a redelivery conflicts instead of recording twice.
+	id := uuid.NewSHA1(evt.AggregateId, []byte(saga+"/"+compensationKey(evt)))
+	return recorder.record(ctx, &Event{
+		Service:       service,
+		Namespace:     GetNamespace(ctx),
+		AggregateId:   id,
+		AggregateType: saga,
+		Version:       1,
+		Type:          utils.GetTypeName(compensated),
+		By:            GetActor(ctx),
+		Timestamp:     GetTime(ctx),
+		Data:          compensated,
+		Metadata:      GetMetadata(ctx),
+	})
+}
+
+// compensationKey identifies the handled event, timeouts and events without
+// a version aren't unique by their version.
+func compensationKey(evt *Event) string {
+	if timeout, ok := evt.Data.(*ProcessTimeout); ok && timeout.Id != uuid.Nil {
+		return fmt.Sprintf("%s/%s/timeout/%s", evt.AggregateType, evt.Type, timeout.Id)
+	}
+	if evt.Version == 0 {
+		return fmt.Sprintf("%s/%s/%s/%s@%d", evt.Namespace, evt.AggregateType, evt.Type, evt.AggregateId, evt.Timestamp.UnixNano())
+	}
+	return fmt.Sprintf("%s/%s/%s@%d", evt.Namespace, evt.AggregateType, evt.Type, evt.Version)
+}
@@ -0,0 +1,141 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type stepCommand struct {
+	BaseCommand
+
+	Name string
+}
+
+type stepUnit struct {
+	Unit
+
+	events     []*Event
+	fail       map[string]bool
+	dispatched []string
+}
+
+func (u *stepUnit) record(ctx context.Context, events ...*Event) error {
+	u.events = append(u.events, events...)
+	return nil
+}
+
+func (u *stepUnit) Dispatch(ctx context.Context, cmds ...Command) ([]*DispatchResult, error) {
+	for _, cmd := range cmds {
+		name := cmd.(*stepCommand).Name
+		if u.fail[name] {
+			return nil, errors.New(name + " failed")
+		}
+		u.dispatched = append(u.dispatched, name)
+	}
+	return nil, nil
+}
+
+func step(name string) *stepCommand {
+	return &stepCommand{Name: name}
+}
+
+func Test_DispatchSteps(t *testing.T) {
+	evt := &Event{AggregateId: uuid.New(), AggregateType: "Order", Type: "OrderPlaced", Version: 1}
+
+	tests := []struct {
+		name       string
+		fail       map[string]bool
+		dispatched []string
+		recorded   interface{}
+		err        bool
+	}{
+		{
+			name:       "success",
+			dispatched: []string{"reserve", "charge", "ship"},
+		},
+		{
+			name:       "compensated",
+			fail:       map[string]bool{"ship": true},
+			dispatched: []string{"reserve", "charge", "refund", "release"},
+			recorded: &SagaCompensated{
+				Saga:          "OrderSaga",
+				Step:          2,
+				Error:         "ship failed",
+				Compensations: []string{"stepCommand", "stepCommand"},
+			},
+		},
+		{
+			name:       "compensation failed",
+			fail:       map[string]bool{"ship": true, "refund": true},
+			dispatched: []string{"reserve", "charge", "release"},
+			err:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := &stepUnit{fail: tt.fail}
+			ctx := SetUnit(context.Background(), unit)
+
+			cmds := Commands(
+				NewStep(step("reserve"), step("release")),
+				NewStep(step("charge"), step("refund")),
+				NewStep(step("ship"), nil),
+			)
+			err := dispatchSteps(ctx, "orders", "OrderSaga", evt, cmds)
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if len(unit.dispatched) != len(tt.dispatched) {
+				t.Fatalf("expected %v, got %v", tt.dispatched, unit.dispatched)
+			}
+			for i, name := range tt.dispatched {
+				if unit.dispatched[i] != name {
+					t.Fatalf("expected %v, got %v", tt.dispatched, unit.dispatched)
+				}
+			}
+
+			if tt.recorded == nil {
+				if len(unit.events) != 0 {
+					t.Fatalf("expected no events, got %v", unit.events)
+				}
+				return
+			}
+			if len(unit.events) != 1 {
+				t.Fatalf("expected one event, got %v", unit.events)
+			}
+			recorded := unit.events[0]
+			if recorded.AggregateType != "OrderSaga" || recorded.Service != "orders" {
+				t.Errorf("unexpected stream %s/%s", recorded.Service, recorded.AggregateType)
+			}
+			if !reflect.DeepEqual(tt.recorded, recorded.Data) {
+				t.Errorf("expected %+v, got %+v", tt.recorded, recorded.Data)
+			}
+		})
+	}
+}
+
+func Test_CompensationKey(t *testing.T) {
+	id := uuid.New()
+	first := &Event{AggregateId: id, AggregateType: "Onboarding", Type: "ProcessTimeout", Data: &ProcessTimeout{Id: uuid.New(), Name: "reminder"}}
+	second := &Event{AggregateId: id, AggregateType: "Onboarding", Type: "ProcessTimeout", Data: &ProcessTimeout{Id: uuid.New(), Name: "reminder"}}
+	if compensationKey(first) == compensationKey(second) {
+		t.Error("expected timeouts to have their own key")
+	}
+
+	now := time.Now()
+	held := &Event{AggregateId: id, AggregateType: "User", Type: "UserCreated", Timestamp: now}
+	later := &Event{AggregateId: id, AggregateType: "User", Type: "UserCreated", Timestamp: now.Add(time.Second)}
+	if compensationKey(held) == compensationKey(later) {
+		t.Error("expected events without a version to have their own key")
+	}
+	if compensationKey(held) != compensationKey(&Event{AggregateId: id, AggregateType: "User", Type: "UserCreated", Timestamp: now}) {
+		t.Error("expected a redelivered event to have the same key")
+	}
+}
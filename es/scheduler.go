@@ -0,0 +1,158 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scheduler manages the commands persisted for later execution
+// in the namespace of the context.
+type Scheduler interface {
+	Schedule(ctx context.Context, cmd Command, executeAfter time.Time) (uuid.UUID, error)
+	ScheduleRecurring(ctx context.Context, cmd Command, recurrence string) (uuid.UUID, error)
+	Cancel(ctx context.Context, id uuid.UUID) error
+	Reschedule(ctx context.Context, id uuid.UUID, executeAfter time.Time) error
+	List(ctx context.Context, filter Filter) ([]*PersistedCommand, error)
+}
+
+type unitScheduler struct {
+	unit *unit
+}
+
+func (s *unitScheduler) Schedule(ctx context.Context, cmd Command, executeAfter time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.unit.work(ctx, func(ctx context.Context) (err error) {
+		id, err = s.unit.schedule(ctx, cmd, executeAfter, "")
+		return err
+	})
+	return id, err
+}
+
+func (s *unitScheduler) ScheduleRecurring(ctx context.Context, cmd Command, recurrence string) (uuid.UUID, error) {
+	r, err := ParseRecurrence(recurrence)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	next := r.Next(GetTime(ctx))
+	if next.IsZero() {
+		return uuid.Nil, fmt.Errorf("recurrence %s never runs", recurrence)
+	}
+
+	var id uuid.UUID
+	err = s.unit.work(ctx, func(ctx context.Context) (err error) {
+		id, err = s.unit.schedule(ctx, cmd, next, recurrence)
+		return err
+	})
+	return id, err
+}
+
+func (s *unitScheduler) Cancel(ctx context.Context, id uuid.UUID) error {
+	return s.unit.work(ctx, func(ctx context.Context) error {
+		persistedCommand, err := s.get(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.unit.data.DeletePersistedCommand(ctx, persistedCommand)
+	})
+}
+
+func (s *unitScheduler) Reschedule(ctx context.Context, id uuid.UUID, executeAfter time.Time) error {
+	return s.unit.work(ctx, func(ctx context.Context) error {
+		persistedCommand, err := s.get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// a rescheduled command starts over, even when it was dead lettered.
+		persistedCommand.ExecuteAfter = executeAfter
+		persistedCommand.Attempts = 0
+		persistedCommand.LastError = ""
+		persistedCommand.NextAttemptAt = nil
+		persistedCommand.DeadLetteredAt = nil
+		return s.unit.data.SavePersistedCommand(ctx, persistedCommand)
+	})
+}
+
+func (s *unitScheduler) List(ctx context.Context, filter Filter) ([]*PersistedCommand, error) {
+	namespace := WhereClause{Column: "namespace", Op: OpEqual, Args: GetNamespace(ctx)}
+	if filter.Where == nil {
+		filter.Where = namespace
+	} else {
+		filter.Where = []Where{filter.Where, namespace}
+	}
+	if len(filter.Order) == 0 {
+		filter.Order = []Order{{Expression: dueColumn}}
+	}
+	return s.unit.data.FindPersistedCommands(ctx, filter)
+}
+
+func (s *unitScheduler) get(ctx context.Context, id uuid.UUID) (*PersistedCommand, error) {
+	persistedCommands, err := s.List(ctx, Filter{
+		Where: WhereClause{Column: "id", Op: OpEqual, Args: id},
+		Limit: Limit(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(persistedCommands) == 0 {
+		return nil, fmt.Errorf("persisted command %s: %w", id, ErrNotFound)
+	}
+	return persistedCommands[0], nil
+}
+
+// clientScheduler resolves the unit from the context on every call.
+type clientScheduler struct {
+	client *client
+}
+
+func (s *clientScheduler) scheduler(ctx context.Context) (Scheduler, error) {
+	unit, err := s.client.Unit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return unit.Scheduler(), nil
+}
+
+func (s *clientScheduler) Schedule(ctx context.Context, cmd Command, executeAfter time.Time) (uuid.UUID, error) {
+	scheduler, err := s.scheduler(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return scheduler.Schedule(ctx, cmd, executeAfter)
+}
+
+func (s *clientScheduler) ScheduleRecurring(ctx context.Context, cmd Command, recurrence string) (uuid.UUID, error) {
+	scheduler, err := s.scheduler(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return scheduler.ScheduleRecurring(ctx, cmd, recurrence)
+}
+
+func (s *clientScheduler) Cancel(ctx context.Context, id uuid.UUID) error {
+	scheduler, err := s.scheduler(ctx)
+	if err != nil {
+		return err
+	}
+	return scheduler.Cancel(ctx, id)
+}
+
+func (s *clientScheduler) Reschedule(ctx context.Context, id uuid.UUID, executeAfter time.Time) error {
+	scheduler, err := s.scheduler(ctx)
+	if err != nil {
+		return err
+	}
+	return scheduler.Reschedule(ctx, id, executeAfter)
+}
+
+func (s *clientScheduler) List(ctx context.Context, filter Filter) ([]*PersistedCommand, error) {
+	scheduler, err := s.scheduler(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.List(ctx, filter)
+}
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-apis/eventsourcing/es"
+)
+
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+type Message struct {
+	Name     string   `json:"name"`
+	Title    string   `json:"title,omitempty"`
+	Payload  *Schema  `json:"payload"`
+	Service  string   `json:"x-service"`
+	Aliases  []string `json:"x-aliases,omitempty"`
+	Protocol string   `json:"x-protocol,omitempty"`
+}
+
+type Channel struct {
+	Address  string          `json:"address"`
+	Messages map[string]*Ref `json:"messages"`
+}
+
+type AsyncOperation struct {
+	Action   string `json:"action"`
+	Channel  *Ref   `json:"channel"`
+	Messages []*Ref `json:"messages"`
+}
+
+type AsyncComponents struct {
+	Messages map[string]*Message `json:"messages"`
+	Schemas  map[string]*Schema  `json:"schemas"`
+}
+
+// AsyncDocument is an AsyncAPI 3 document.
+type AsyncDocument struct {
+	AsyncAPI   string                     `json:"asyncapi"`
+	Info       Info                       `json:"info"`
+	Channels   map[string]*Channel        `json:"channels"`
+	Operations map[string]*AsyncOperation `json:"operations"`
+	Components AsyncComponents            `json:"components"`
+}
+
+// channel returns the id and address an event is published on for the stream.
+func channel(stream es.StreamConfig, evt *es.EventConfig) (string, string) {
+	switch stream.Type {
+	case "nats":
+		if stream.Nats != nil {
+			address := fmt.Sprintf("%s.%s.%s", stream.Nats.Subject, evt.Service, evt.Name)
+			return address, address
+		}
+	case "pubsub":
+		if stream.PubSub != nil {
+			return stream.PubSub.TopicId, stream.PubSub.TopicId
+		}
+	case "apub":
+		if stream.AWS != nil {
+			return "sns", stream.AWS.TopicArn
+		}
+	case "mpub":
+		if stream.Memory != nil {
+			return stream.Memory.Topic, stream.Memory.Topic
+		}
+	}
+
+	address := evt.Service + "." + evt.Name
+	return address, address
+}
+
+// AsyncAPI lists every published event and the channel it goes out on.
+func AsyncAPI(reg es.Registry, pcfg *es.ProviderConfig) *AsyncDocument {
+	g := NewGenerator(refPrefix)
+
+	doc := &AsyncDocument{
+		AsyncAPI: "3.0.0",
+		Info: Info{
+			Title:   pcfg.Service,
+			Version: pcfg.Version,
+		},
+		Channels:   map[string]*Channel{},
+		Operations: map[string]*AsyncOperation{},
+		Components: AsyncComponents{
+			Messages: map[string]*Message{},
+			Schemas:  g.Defs,
+		},
+	}
+
+	for _, evt := range reg.GetEventConfigs() {
+		if !evt.Publish {
+			continue
+		}
+
+		// the event is wrapped in the envelope it is published with.
+		payload := g.object(reflect.TypeOf(es.Event{}))
+		payload.Properties["data"] = g.Ref(evt.Type)
+
+		id, address := channel(pcfg.Stream, evt)
+		ch, ok := doc.Channels[id]
+		if !ok {
+			ch = &Channel{
+				Address:  address,
+				Messages: map[string]*Ref{},
+			}
+			doc.Channels[id] = ch
+		}
+		ch.Messages[evt.Name] = &Ref{Ref: "#/components/messages/" + evt.Name}
+
+		doc.Components.Messages[evt.Name] = &Message{
+			Name:     evt.Name,
+			Title:    evt.Service + " " + evt.Name,
+			Payload:  payload,
+			Service:  evt.Service,
+			Aliases:  evt.Aliases,
+			Protocol: pcfg.Stream.Type,
+		}
+
+		op, ok := doc.Operations["publish"+id]
+		if !ok {
+			op = &AsyncOperation{
+				Action:  "send",
+				Channel: &Ref{Ref: "#/channels/" + id},
+			}
+			doc.Operations["publish"+id] = op
+		}
+		op.Messages = append(op.Messages, &Ref{Ref: "#/channels/" + id + "/messages/" + evt.Name})
+	}
+
+	return doc
+}
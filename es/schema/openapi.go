@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-apis/eventsourcing/es"
+)
+
+const refPrefix = "#/components/schemas/"
+
+// Info about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// PathItem by lowered http method.
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Document is an OpenAPI 3.1 document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json": {Schema: s},
+	}
+}
+
+func errorResponse(description string) *Response {
+	return &Response{
+		Description: description,
+		Content:     jsonContent(&Schema{Ref: refPrefix + "Error"}),
+	}
+}
+
+func pagination(items *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"limit":       {Type: "integer"},
+			"page":        {Type: "integer"},
+			"total_items": {Type: "integer"},
+			"total_pages": {Type: "integer"},
+			"items":       {Type: "array", Items: items},
+		},
+		Required: []string{"limit", "page", "total_items", "total_pages", "items"},
+	}
+}
+
+// OpenAPI describes the command gateway and the read endpoints of every entity.
+func OpenAPI(reg es.Registry, info Info) *Document {
+	g := NewGenerator(refPrefix)
+	g.Ref(reflect.TypeOf(es.DispatchResult{}))
+	g.Defs["Error"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error":  {Type: "string"},
+			"errors": {Type: "array", Items: g.Ref(reflect.TypeOf(es.FieldError{}))},
+		},
+		Required: []string{"error"},
+	}
+
+	paths := map[string]PathItem{}
+	for _, cmd := range reg.GetCommandConfigs() {
+		if cmd.Internal {
+			continue
+		}
+		paths["/commands/"+strings.ToLower(cmd.Name)] = PathItem{
+			strings.ToLower(http.MethodPost): {
+				OperationId: cmd.Name,
+				Tags:        []string{"commands"},
+				RequestBody: &RequestBody{
+					Required: true,
+					Content:  jsonContent(g.Ref(cmd.Type)),
+				},
+				Responses: map[string]*Response{
+					"200": {
+						Description: "The command was dispatched",
+						Content:     jsonContent(&Schema{Ref: refPrefix + "DispatchResult"}),
+					},
+					"400": errorResponse("The command is invalid"),
+					"403": errorResponse("The actor is not allowed to run the command"),
+					"404": errorResponse("The command or aggregate was not found"),
+					"409": errorResponse("The aggregate was changed concurrently"),
+				},
+			},
+		}
+	}
+
+	for _, entity := range reg.GetEntities() {
+		name := strings.ToLower(entity.Name)
+		ref := g.Ref(entity.Type)
+
+		paths["/"+name] = PathItem{
+			strings.ToLower(http.MethodGet): {
+				OperationId: "find" + entity.Name,
+				Tags:        []string{name},
+				Parameters: []*Parameter{
+					{Name: "limit", In: "query", Schema: &Schema{Type: "integer"}},
+					{Name: "offset", In: "query", Schema: &Schema{Type: "integer"}},
+				},
+				Responses: map[string]*Response{
+					"200": {
+						Description: "A page of " + entity.Name,
+						Content:     jsonContent(pagination(ref)),
+					},
+				},
+			},
+		}
+		paths["/"+name+"/{id}"] = PathItem{
+			strings.ToLower(http.MethodGet): {
+				OperationId: "get" + entity.Name,
+				Tags:        []string{name},
+				Parameters: []*Parameter{
+					{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}},
+				},
+				Responses: map[string]*Response{
+					"200": {
+						Description: "The " + entity.Name,
+						Content:     jsonContent(ref),
+					},
+					"404": errorResponse("The " + entity.Name + " was not found"),
+				},
+			},
+		}
+	}
+
+	return &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   paths,
+		Components: Components{
+			Schemas: g.Defs,
+		},
+	}
+}
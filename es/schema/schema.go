@@ -0,0 +1,203 @@
+package schema
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-apis/eventsourcing/es"
+	"github.com/google/uuid"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema (draft 2020-12).
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawType     = reflect.TypeOf(json.RawMessage{})
+	commandType = reflect.TypeOf((*es.Command)(nil)).Elem()
+)
+
+// Generator builds schemas for Go types. Named structs are added to Defs
+// once and referenced with RefPrefix.
+type Generator struct {
+	RefPrefix string
+	Defs      map[string]*Schema
+
+	names map[reflect.Type]string
+}
+
+func (g *Generator) name(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, ok := g.Defs[name]; ok {
+		// same name in another package.
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	g.names[t] = name
+	return name
+}
+
+// Ref adds the type to Defs and returns a reference to it.
+func (g *Generator) Ref(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Name() == "" || t == timeType {
+		return g.Schema(t)
+	}
+
+	name := g.name(t)
+	if _, ok := g.Defs[name]; !ok {
+		// reserve it for recursive types.
+		g.Defs[name] = &Schema{}
+		*g.Defs[name] = *g.object(t)
+	}
+	return &Schema{Ref: g.RefPrefix + name}
+}
+
+// Schema for the type, with named structs referenced.
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Ref(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Ref(t.Elem())}
+	case reflect.Struct:
+		if t.Name() != "" {
+			return g.Ref(t)
+		}
+		return g.object(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *Generator) object(t reflect.Type) *Schema {
+	out := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+	g.fields(out, t)
+	return out
+}
+
+func (g *Generator) fields(out *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && ft.Kind() == reflect.Struct {
+			required := len(out.Required)
+			g.fields(out, ft)
+			// embedded structs tagged required:"false" are optional.
+			if field.Tag.Get("required") == "false" {
+				out.Required = out.Required[:required]
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		var prop *Schema
+		if ft.Kind() == reflect.Interface && ft.Implements(commandType) {
+			prop = &Schema{Type: "object"}
+		} else {
+			prop = g.Ref(field.Type)
+		}
+		if format := field.Tag.Get("format"); format != "" && prop.Ref == "" {
+			prop.Format = format
+		}
+		out.Properties[name] = prop
+
+		if field.Tag.Get("required") == "true" {
+			out.Required = append(out.Required, name)
+		}
+	}
+}
+
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{
+		RefPrefix: refPrefix,
+		Defs:      map[string]*Schema{},
+		names:     map[reflect.Type]string{},
+	}
+}
+
+// JSONSchema with every command, event and entity of the registry in $defs.
+func JSONSchema(reg es.Registry) *Schema {
+	g := NewGenerator("#/$defs/")
+	for _, cmd := range reg.GetCommandConfigs() {
+		if cmd.Internal {
+			continue
+		}
+		g.Ref(cmd.Type)
+	}
+	for _, evt := range reg.GetEventConfigs() {
+		g.Ref(evt.Type)
+	}
+	for _, entity := range reg.GetEntities() {
+		g.Ref(entity.Type)
+	}
+
+	return &Schema{
+		Schema: draft,
+		Defs:   g.Defs,
+	}
+}
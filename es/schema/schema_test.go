@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-apis/eventsourcing/es"
+)
+
+type Address struct {
+	Street string `json:"street" required:"true"`
+}
+
+type CreateAccount struct {
+	es.BaseCommand
+
+	Name    string   `json:"name" required:"true"`
+	OwnerId string   `json:"owner_id" format:"uuid"`
+	Address *Address `json:"address"`
+}
+
+type CloseAccount struct {
+	es.BaseCommand
+	es.BaseNamespaceCommand `required:"false"`
+}
+
+type AccountCreated struct {
+	es.BaseEvent `es:"publish;alias=AccountOpened"`
+
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Account struct {
+	es.BaseAggregateSourced
+
+	Name string `json:"name"`
+}
+
+func (a *Account) HandleCreate(ctx context.Context, cmd *CreateAccount) error {
+	return nil
+}
+
+func (a *Account) HandleClose(ctx context.Context, cmd *CloseAccount) error {
+	return nil
+}
+
+func Test_Schema(t *testing.T) {
+	reg, err := es.NewRegistry("test", &Account{}, &AccountCreated{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	t.Run("JSONSchema", func(t *testing.T) {
+		s := JSONSchema(reg)
+
+		cmd, ok := s.Defs["CreateAccount"]
+		if !ok {
+			t.Fatalf("expected CreateAccount in $defs")
+		}
+		if got := cmd.Properties["aggregate_id"].Format; got != "uuid" {
+			t.Errorf("expected uuid format, got %s", got)
+		}
+		if got := cmd.Properties["owner_id"].Format; got != "uuid" {
+			t.Errorf("expected uuid format, got %s", got)
+		}
+		if got := cmd.Properties["address"].Ref; got != "#/$defs/Address" {
+			t.Errorf("expected address ref, got %s", got)
+		}
+		if len(cmd.Required) != 2 || cmd.Required[0] != "aggregate_id" || cmd.Required[1] != "name" {
+			t.Errorf("unexpected required: %v", cmd.Required)
+		}
+		if closeCmd := s.Defs["CloseAccount"]; closeCmd == nil || len(closeCmd.Required) != 1 {
+			t.Errorf("expected only the aggregate id to be required, got %v", closeCmd)
+		}
+
+		evt, ok := s.Defs["AccountCreated"]
+		if !ok {
+			t.Fatalf("expected AccountCreated in $defs")
+		}
+		if got := evt.Properties["created_at"].Format; got != "date-time" {
+			t.Errorf("expected date-time format, got %s", got)
+		}
+
+		if _, ok := s.Defs["Account"]; !ok {
+			t.Errorf("expected Account in $defs")
+		}
+	})
+
+	t.Run("OpenAPI", func(t *testing.T) {
+		doc := OpenAPI(reg, Info{Title: "test", Version: "v1"})
+
+		op, ok := doc.Paths["/commands/createaccount"]["post"]
+		if !ok {
+			t.Fatalf("expected command path")
+		}
+		if got := op.RequestBody.Content["application/json"].Schema.Ref; got != "#/components/schemas/CreateAccount" {
+			t.Errorf("unexpected request body: %s", got)
+		}
+		if _, ok := doc.Paths["/commands/closeaccount"]["post"]; !ok {
+			t.Errorf("expected close command path")
+		}
+
+		find, ok := doc.Paths["/account"]["get"]
+		if !ok {
+			t.Fatalf("expected find path")
+		}
+		if len(find.Parameters) != 2 || find.Parameters[0].Name != "limit" || find.Parameters[1].Name != "offset" {
+			t.Errorf("expected limit and offset parameters, got %+v", find.Parameters)
+		}
+		get, ok := doc.Paths["/account/{id}"]["get"]
+		if !ok {
+			t.Fatalf("expected get path")
+		}
+		if got := get.Responses["200"].Content["application/json"].Schema.Ref; got != "#/components/schemas/Account" {
+			t.Errorf("unexpected get response: %s", got)
+		}
+		if _, ok := doc.Components.Schemas["DispatchResult"]; !ok {
+			t.Errorf("expected DispatchResult schema")
+		}
+	})
+
+	t.Run("AsyncAPI", func(t *testing.T) {
+		doc := AsyncAPI(reg, &es.ProviderConfig{
+			Service: "test",
+			Version: "v1",
+			Stream: es.StreamConfig{
+				Type: "nats",
+				Nats: &es.NatsConfig{Subject: "events"},
+			},
+		})
+
+		ch, ok := doc.Channels["events.test.AccountCreated"]
+		if !ok {
+			t.Fatalf("expected channel, got %v", doc.Channels)
+		}
+		if ch.Address != "events.test.AccountCreated" {
+			t.Errorf("unexpected address: %s", ch.Address)
+		}
+
+		msg, ok := doc.Components.Messages["AccountCreated"]
+		if !ok {
+			t.Fatalf("expected message")
+		}
+		if msg.Service != "test" || len(msg.Aliases) != 1 || msg.Aliases[0] != "AccountOpened" {
+			t.Errorf("unexpected message: %+v", msg)
+		}
+		if got := msg.Payload.Properties["data"].Ref; got != "#/components/schemas/AccountCreated" {
+			t.Errorf("unexpected data: %s", got)
+		}
+	})
+}
@@ -3,11 +3,14 @@ package es
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-apis/eventsourcing/es/utils"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type Unit interface {
@@ -17,6 +20,7 @@ type Unit interface {
 	One(ctx context.Context, aggregateName string, namespace string, filter Filter, out interface{}) error
 	Find(ctx context.Context, aggregateName string, namespace string, filter Filter, out interface{}) error
 	Count(ctx context.Context, aggregateName string, namespace string, filter Filter) (int, error)
+	Aggregate(ctx context.Context, aggregateName string, namespace string, filter Filter, groupBy []GroupBy, measures []Measure) ([]AggregateRow, error)
 
 	Load(ctx context.Context, name string, id uuid.UUID, opts ...DataLoadOption) (Entity, error)
 	Save(ctx context.Context, name string, aggregate Entity) error
@@ -26,7 +30,11 @@ type Unit interface {
 	FindEvents(ctx context.Context, filter Filter) ([]*Event, error)
 
 	Handle(ctx context.Context, group string, events ...*Event) error
-	Dispatch(ctx context.Context, cmds ...Command) error
+	Dispatch(ctx context.Context, cmds ...Command) ([]*DispatchResult, error)
+	Scheduler() Scheduler
+
+	// Work runs fn in a transaction, rolling back the unit when it fails.
+	Work(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type unit struct {
@@ -38,6 +46,10 @@ type unit struct {
 	publisher EventPublisher
 
 	events []*Event
+	depth  int
+
+	// entities loaded within the current transaction.
+	entities map[string]Entity
 }
 
 func (u *unit) Data() Data {
@@ -60,8 +72,49 @@ func (u *unit) Count(ctx context.Context, aggregateName string, namespace string
 	return u.data.Count(ctx, aggregateName, namespace, filter)
 }
 
+func (u *unit) Aggregate(ctx context.Context, aggregateName string, namespace string, filter Filter, groupBy []GroupBy, measures []Measure) ([]AggregateRow, error) {
+	return u.data.Aggregate(ctx, aggregateName, namespace, filter, groupBy, measures)
+}
+
+func entityKey(name string, namespace string, id uuid.UUID) string {
+	return strings.ToLower(name) + "__" + namespace + "__" + id.String()
+}
+
 func (u *unit) Load(ctx context.Context, name string, id uuid.UUID, opts ...DataLoadOption) (Entity, error) {
-	return u.dataStore.Load(ctx, name, id, opts...)
+	options := &DataLoadOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	u.RLock()
+	inTx := u.depth > 0
+	key := entityKey(name, GetNamespace(ctx), id)
+	entity, ok := u.entities[key]
+	u.RUnlock()
+
+	if ok && !options.Force {
+		return entity, nil
+	}
+
+	loaded, err := u.dataStore.Load(ctx, name, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// refresh in place so everyone holding the entity sees the new state.
+	if ok && reflect.TypeOf(entity) == reflect.TypeOf(loaded) && reflect.TypeOf(entity).Kind() == reflect.Ptr {
+		reflect.ValueOf(entity).Elem().Set(reflect.ValueOf(loaded).Elem())
+		return entity, nil
+	}
+	entity = loaded
+
+	// only hold on to entities for the lifetime of the transaction.
+	if inTx {
+		u.Lock()
+		u.entities[key] = entity
+		u.Unlock()
+	}
+	return entity, nil
 }
 
 func (u *unit) Save(ctx context.Context, name string, aggregate Entity) error {
@@ -70,6 +123,12 @@ func (u *unit) Save(ctx context.Context, name string, aggregate Entity) error {
 		return err
 	}
 
+	u.Lock()
+	if u.depth > 0 {
+		u.entities[entityKey(name, GetNamespace(ctx), aggregate.GetId())] = aggregate
+	}
+	u.Unlock()
+
 	// do something with events.
 	u.events = append(u.events, evts...)
 
@@ -82,11 +141,36 @@ func (u *unit) Save(ctx context.Context, name string, aggregate Entity) error {
 	return nil
 }
 
+func (u *unit) record(ctx context.Context, events ...*Event) error {
+	if err := u.data.SaveEvents(ctx, events); err != nil {
+		return err
+	}
+
+	u.Lock()
+	u.events = append(u.events, events...)
+	u.Unlock()
+
+	for _, evt := range events {
+		if err := u.registry.HandleGroupEvent(ctx, InternalGroup, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (u *unit) Delete(ctx context.Context, name string, aggregate Entity) error {
+	u.Lock()
+	delete(u.entities, entityKey(name, GetNamespace(ctx), aggregate.GetId()))
+	u.Unlock()
+
 	return u.dataStore.Delete(ctx, name, aggregate)
 }
 
 func (u *unit) Truncate(ctx context.Context, name string) error {
+	u.Lock()
+	u.entities = make(map[string]Entity)
+	u.Unlock()
+
 	return u.dataStore.Truncate(ctx, name)
 }
 
@@ -94,6 +178,10 @@ func (u *unit) FindEvents(ctx context.Context, filter Filter) ([]*Event, error)
 	return u.data.FindEvents(ctx, filter)
 }
 
+func (u *unit) Work(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.work(SetUnit(ctx, u), fn)
+}
+
 func (u *unit) work(ctx context.Context, fn func(ctx context.Context) error) (err error) {
 	tx, err := u.data.Begin(ctx)
 	if err != nil {
@@ -102,11 +190,35 @@ func (u *unit) work(ctx context.Context, fn func(ctx context.Context) error) (er
 
 	skipPublish := GetSkipPublish(ctx)
 
+	u.Lock()
+	u.depth++
+	outer := u.depth == 1
+	start := len(u.events)
+	u.Unlock()
+
+	defer func() {
+		u.Lock()
+		u.depth--
+		if u.depth == 0 {
+			u.entities = make(map[string]Entity)
+		}
+		u.Unlock()
+	}()
+
 	defer func() {
 		if perr := recover(); perr != nil {
 			err = fmt.Errorf("panic: %v", perr)
 		}
 		if err != nil {
+			// forget what the rolled back work loaded and raised.
+			u.Lock()
+			u.entities = make(map[string]Entity)
+			if start <= len(u.events) {
+				u.events = u.events[:start]
+			}
+			u.Unlock()
+
+			u.dataStore.Rollback(ctx)
 			if rerr := tx.Rollback(ctx); rerr != nil {
 				err = fmt.Errorf("rolling back transaction fail: %s\n %w ", rerr.Error(), err)
 			}
@@ -122,6 +234,10 @@ func (u *unit) work(ctx context.Context, fn func(ctx context.Context) error) (er
 		return fmt.Errorf("committing transaction fail: %w", rerr)
 	}
 
+	if outer {
+		u.dataStore.Commit(ctx)
+	}
+
 	// publish events?
 	if !skipPublish {
 		for _, evt := range u.events {
@@ -144,18 +260,41 @@ func (u *unit) work(ctx context.Context, fn func(ctx context.Context) error) (er
 	return nil
 }
 
-func (u *unit) schedule(ctx context.Context, cmd Command, executeAfter time.Time) (uuid.UUID, error) {
-	by := GetActor(ctx)
+func (u *unit) Scheduler() Scheduler {
+	return &unitScheduler{unit: u}
+}
+
+func (u *unit) schedule(ctx context.Context, cmd Command, executeAfter time.Time, recurrence string) (uuid.UUID, error) {
+	if err := ValidateCommand(ctx, cmd); err != nil {
+		return uuid.Nil, err
+	}
+
+	commandType := utils.GetTypeName(cmd)
+	scheduledBy := newScheduledBy(GetEvent(ctx))
+	id, err := scheduledId(scheduledBy, commandType, cmd, executeAfter)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	trace := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, trace)
+
+	metadata, _ := ctx.Value(MetadataKey).(map[string]interface{})
+
 	persistedCommand := &PersistedCommand{
-		Id:           uuid.New(),
+		Id:           id,
 		Namespace:    GetNamespace(ctx),
-		CommandType:  utils.GetTypeName(cmd),
+		CommandType:  commandType,
 		Command:      cmd,
 		ExecuteAfter: executeAfter,
 		CreatedAt:    time.Now(),
-		By:           by,
+		By:           GetActor(ctx),
+		Recurrence:   recurrence,
+		Metadata:     metadata,
+		Trace:        trace,
+		ScheduledBy:  scheduledBy,
 	}
-	if err := u.data.SavePersistedCommand(ctx, persistedCommand); err != nil {
+	if err := u.data.CreatePersistedCommand(ctx, persistedCommand); err != nil {
 		return uuid.Nil, err
 	}
 
@@ -170,51 +309,99 @@ func (u *unit) Handle(ctx context.Context, group string, events ...*Event) error
 	ctx = SetUnit(ctx, u)
 
 	return u.work(ctx, func(ctx context.Context) error {
+		dedupe := GetDeduplicate(ctx)
 		for _, evt := range events {
-			if err := u.registry.HandleGroupEvent(ctx, group, evt); err != nil {
+			// redelivered events were marked in the transaction that handled them.
+			// Events without a version come from aggregate holders and have
+			// nothing unique to mark, so they are always handled.
+			if dedupe && evt.Version > 0 {
+				first, err := u.data.MarkEventProcessed(ctx, group, evt)
+				if err != nil {
+					return err
+				}
+				if !first {
+					continue
+				}
+			}
+			if err := u.registry.HandleGroupEvent(SetEvent(ctx, evt), group, evt); err != nil {
 				return err
 			}
 		}
 		return nil
 	})
 }
-func (u *unit) Dispatch(ctx context.Context, cmds ...Command) error {
+func (u *unit) Dispatch(ctx context.Context, cmds ...Command) ([]*DispatchResult, error) {
 	if len(cmds) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	ctx = SetUnit(ctx, u)
 
-	return u.work(ctx, func(ctx context.Context) error {
+	results := make([]*DispatchResult, 0, len(cmds))
+	err := u.work(ctx, func(ctx context.Context) error {
 		for _, cmd := range cmds {
 			scheduled, ok := cmd.(ScheduledCommand)
 			if ok {
-				if _, err := u.schedule(ctx, scheduled.GetCommand(), scheduled.ExecuteAfter()); err != nil {
+				id, err := u.schedule(ctx, scheduled.GetCommand(), scheduled.ExecuteAfter(), "")
+				if err != nil {
 					return err
 				}
-				return nil
+				results = append(results, &DispatchResult{
+					AggregateId: scheduled.GetAggregateId(),
+					ScheduledId: id,
+				})
+				continue
 			}
 
+			u.RLock()
+			start := len(u.events)
+			u.RUnlock()
+
 			if err := u.registry.HandleCommand(ctx, cmd); err != nil {
 				return err
 			}
+
+			results = append(results, u.dispatchResult(cmd, start))
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (u *unit) dispatchResult(cmd Command, start int) *DispatchResult {
+	u.RLock()
+	defer u.RUnlock()
+
+	result := &DispatchResult{
+		AggregateId: cmd.GetAggregateId(),
+	}
+	// events from other aggregates were raised by handlers further down.
+	for _, evt := range u.events[start:] {
+		if evt.AggregateId != result.AggregateId {
+			continue
+		}
+		result.Events = append(result.Events, evt)
+		result.Version = evt.Version
+	}
+	return result
 }
 
-func newUnit(ctx context.Context, service string, registry Registry, conn Conn, publisher EventPublisher) (Unit, error) {
+func newUnit(ctx context.Context, service string, registry Registry, conn Conn, publisher EventPublisher, cache *AggregateCache) (Unit, error) {
 	data, err := conn.NewData(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	ds := NewDataStore(service, data, registry)
+	ds := NewDataStore(service, data, registry, cache)
 
 	return &unit{
 		data:      data,
 		registry:  registry,
 		dataStore: ds,
 		publisher: publisher,
+		entities:  make(map[string]Entity),
 	}, nil
 }
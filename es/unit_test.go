@@ -0,0 +1,118 @@
+package es
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type identityEntity struct {
+	BaseAggregateSourced
+}
+
+type identityTx struct{}
+
+func (identityTx) Commit(ctx context.Context) error   { return nil }
+func (identityTx) Rollback(ctx context.Context) error { return nil }
+
+type identityData struct {
+	Data
+}
+
+func (identityData) Begin(ctx context.Context) (Tx, error) {
+	return identityTx{}, nil
+}
+
+// identityStore counts the loads that reach it, version is the stored state.
+type identityStore struct {
+	DataStore
+
+	loads   int
+	version int
+}
+
+func (s *identityStore) Load(ctx context.Context, name string, id uuid.UUID, opts ...DataLoadOption) (Entity, error) {
+	s.loads++
+	entity := &identityEntity{}
+	entity.Id = id
+	entity.Version = s.version
+	return entity, nil
+}
+
+func (s *identityStore) Save(ctx context.Context, name string, aggregate Entity) ([]*Event, error) {
+	s.version++
+	aggregate.(*identityEntity).Version = s.version
+	return nil, nil
+}
+
+func (s *identityStore) Commit(ctx context.Context)   {}
+func (s *identityStore) Rollback(ctx context.Context) {}
+
+func Test_UnitIdentityMap(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("Same_Instance_In_Transaction", func(t *testing.T) {
+		store := &identityStore{}
+		u := &unit{data: identityData{}, dataStore: store, entities: map[string]Entity{}}
+
+		err := u.work(context.Background(), func(ctx context.Context) error {
+			first, err := u.Load(ctx, "Identity", id)
+			if err != nil {
+				return err
+			}
+			if err := u.Save(ctx, "Identity", first); err != nil {
+				return err
+			}
+
+			// the pending save is visible without going back to the store.
+			second, err := u.Load(ctx, "Identity", id)
+			if err != nil {
+				return err
+			}
+			if first != second {
+				t.Errorf("expected the same instance")
+			}
+			if v := second.(*identityEntity).Version; v != 1 {
+				t.Errorf("expected version 1, got %d", v)
+			}
+			if store.loads != 1 {
+				t.Errorf("expected one load, got %d", store.loads)
+			}
+
+			// forcing a load refreshes the instance everyone is holding.
+			store.version = 5
+			forced, err := u.Load(ctx, "Identity", id, DataLoadForce(true))
+			if err != nil {
+				return err
+			}
+			if forced != first {
+				t.Errorf("expected force to refresh the same instance")
+			}
+			if v := first.(*identityEntity).Version; v != 5 {
+				t.Errorf("expected version 5, got %d", v)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("New_Instance_Outside_Transaction", func(t *testing.T) {
+		store := &identityStore{}
+		u := &unit{data: identityData{}, dataStore: store, entities: map[string]Entity{}}
+
+		first, err := u.Load(context.Background(), "Identity", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := u.Load(context.Background(), "Identity", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == second || store.loads != 2 {
+			t.Errorf("expected every load to reach the store, got %d", store.loads)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Validator can be implemented by commands that need checks beyond their struct tags.
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+// FieldError is a single field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a command fails validation.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+	Err    error        `json:"-"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, ", ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func validateStruct(prefix string, v reflect.Value) []FieldError {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+
+		if field.Anonymous {
+			// embedded structs tagged required:"false" are optional.
+			if field.Tag.Get("required") != "false" {
+				errs = append(errs, validateStruct(prefix, value)...)
+			}
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		name = prefix + name
+
+		if field.Tag.Get("required") == "true" && value.IsZero() {
+			errs = append(errs, FieldError{Field: name, Message: "is required"})
+			continue
+		}
+
+		if field.Tag.Get("format") == "uuid" && value.Kind() == reflect.String && value.Len() > 0 {
+			if _, err := uuid.Parse(value.String()); err != nil {
+				errs = append(errs, FieldError{Field: name, Message: "must be a uuid"})
+				continue
+			}
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			errs = append(errs, validateStruct(name+".", value)...)
+		}
+	}
+	return errs
+}
+
+// ValidateCommand checks the required and format tags of the command
+// and then calls its Validate method if it has one.
+func ValidateCommand(ctx context.Context, cmd Command) error {
+	errs := validateStruct("", reflect.ValueOf(cmd))
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	v, ok := cmd.(Validator)
+	if !ok {
+		return nil
+	}
+	err := v.Validate(ctx)
+	if err == nil {
+		return nil
+	}
+
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return err
+	}
+	var ferr FieldError
+	if errors.As(err, &ferr) {
+		return &ValidationError{Errors: []FieldError{ferr}, Err: err}
+	}
+	return &ValidationError{Errors: []FieldError{{Message: err.Error()}}, Err: err}
+}
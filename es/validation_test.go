@@ -0,0 +1,94 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type validationAddress struct {
+	Street string `json:"street" required:"true"`
+}
+
+type validationCommand struct {
+	BaseCommand
+
+	OwnerId string            `json:"owner_id" format:"uuid"`
+	Address validationAddress `json:"address"`
+}
+
+var errInvalidOwner = errors.New("invalid owner")
+
+func (c *validationCommand) Validate(ctx context.Context) error {
+	if c.OwnerId == c.AggregateId.String() {
+		return errInvalidOwner
+	}
+	return nil
+}
+
+func Test_ValidateCommand(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should_Collect_FieldErrors", func(t *testing.T) {
+		err := ValidateCommand(ctx, &validationCommand{OwnerId: "nope"})
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+		if len(verr.Errors) != 3 {
+			t.Fatalf("expected 3 errors, got %v", verr.Errors)
+		}
+		for i, field := range []string{"aggregate_id", "owner_id", "address.street"} {
+			if verr.Errors[i].Field != field {
+				t.Errorf("expected %s, got %s", field, verr.Errors[i].Field)
+			}
+		}
+	})
+
+	t.Run("Should_Call_Validate", func(t *testing.T) {
+		id := uuid.New()
+		err := ValidateCommand(ctx, &validationCommand{
+			BaseCommand: BaseCommand{AggregateId: id},
+			OwnerId:     id.String(),
+			Address:     validationAddress{Street: "main"},
+		})
+		if !errors.Is(err, errInvalidOwner) {
+			t.Fatalf("expected invalid owner, got %v", err)
+		}
+	})
+
+	t.Run("Should_Pass", func(t *testing.T) {
+		err := ValidateCommand(ctx, &validationCommand{
+			BaseCommand: BaseCommand{AggregateId: uuid.New()},
+			OwnerId:     uuid.NewString(),
+			Address:     validationAddress{Street: "main"},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Should_Require_Namespace", func(t *testing.T) {
+		err := ValidateCommand(ctx, NewReplayCommand("", uuid.New(), "User"))
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Field != "namespace" {
+			t.Fatalf("expected namespace to be required, got %v", err)
+		}
+	})
+
+	t.Run("Should_Skip_Optional_Embedded", func(t *testing.T) {
+		cmd := &struct {
+			BaseCommand
+			BaseNamespaceCommand `required:"false"`
+		}{
+			BaseCommand: BaseCommand{AggregateId: uuid.New()},
+		}
+		if err := ValidateCommand(ctx, cmd); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
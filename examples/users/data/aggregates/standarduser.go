@@ -13,7 +13,7 @@ import (
 )
 
 type StandardUser struct {
-	es.BaseAggregateSourced `es:",snapshot=3"`
+	es.BaseAggregateSourced `es:",snapshot=3,cache=100,cache_ttl=1m"`
 
 	Username    string
 	Password    string
@@ -7,7 +7,7 @@ import (
 
 type CreateUser struct {
 	es.BaseCommand
-	es.BaseNamespaceCommand
+	es.BaseNamespaceCommand `required:"false"`
 
 	Username string
 	Password string
@@ -15,7 +15,7 @@ type CreateUser struct {
 
 type DeleteUser struct {
 	es.BaseCommand
-	es.BaseNamespaceCommand
+	es.BaseNamespaceCommand `required:"false"`
 }
 
 type AddEmail struct {
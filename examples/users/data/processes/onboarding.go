@@ -0,0 +1,40 @@
+package processes
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-apis/eventsourcing/es"
+	"github.com/go-apis/eventsourcing/examples/users/data/events"
+)
+
+// Onboarding follows a user from sign up until they've added an email,
+// reminding them when they haven't after a week.
+type Onboarding struct {
+	es.BaseProcess
+
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Reminded bool   `json:"reminded"`
+}
+
+func (p *Onboarding) HandleUserCreated(ctx context.Context, evt *es.Event, data *events.UserCreated) ([]es.Command, error) {
+	p.Username = data.Username
+	p.RequestTimeout("reminder", 7*24*time.Hour)
+	return nil, nil
+}
+
+func (p *Onboarding) HandleEmailAdded(ctx context.Context, evt *es.Event, data *events.EmailAdded) ([]es.Command, error) {
+	p.Email = data.Email
+	p.Complete()
+	return nil, nil
+}
+
+func (p *Onboarding) HandleTimeout(ctx context.Context, evt *es.Event, data *es.ProcessTimeout) ([]es.Command, error) {
+	p.Reminded = true
+	return nil, nil
+}
+
+func NewOnboarding() *Onboarding {
+	return &Onboarding{}
+}
@@ -7,21 +7,27 @@ import (
 	"github.com/go-apis/eventsourcing/examples/users/data/aggregates"
 	"github.com/go-apis/eventsourcing/examples/users/data/eventhandlers"
 	"github.com/go-apis/eventsourcing/examples/users/data/events"
+	"github.com/go-apis/eventsourcing/examples/users/data/processes"
 	"github.com/go-apis/eventsourcing/examples/users/data/projectors"
 	"github.com/go-apis/eventsourcing/examples/users/data/sagas"
 )
 
-func NewClient(ctx context.Context, pcfg *es.ProviderConfig) (es.Client, error) {
-	reg, err := es.NewRegistry(
-		pcfg.Service,
+func NewRegistry(service string) (es.Registry, error) {
+	return es.NewRegistry(
+		service,
 		&aggregates.StandardUser{},
 		&aggregates.User{},
 		&aggregates.ExternalUser{},
 		sagas.NewConnectionSaga(),
+		processes.NewOnboarding(),
 		projectors.NewUserProjector(),
 		eventhandlers.NewDemoHandler(),
 		&events.GroupAdded{},
 	)
+}
+
+func NewClient(ctx context.Context, pcfg *es.ProviderConfig) (es.Client, error) {
+	reg, err := NewRegistry(pcfg.Service)
 	if err != nil {
 		return nil, err
 	}
@@ -1,14 +1,23 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-apis/eventsourcing/es"
+	"github.com/go-apis/eventsourcing/es/admin"
+	"github.com/go-apis/eventsourcing/es/ops"
 	"github.com/go-apis/eventsourcing/examples/users/data/aggregates"
 	"github.com/go-apis/eventsourcing/examples/users/data/commands"
+	"github.com/go-apis/eventsourcing/examples/users/data/processes"
 	"github.com/go-apis/eventsourcing/examples/users/helpers"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -85,8 +94,13 @@ func Test(t *testing.T) {
 			},
 		}
 
-		errD := unit.Dispatch(ctx, cmds...)
+		results, errD := unit.Dispatch(ctx, cmds...)
 		require.NoError(t, errD)
+		require.Len(t, results, len(cmds))
+		require.Equal(t, userId1, results[0].AggregateId)
+		require.NotEmpty(t, results[0].Events)
+		require.Equal(t, results[0].Events[len(results[0].Events)-1].Version, results[0].Version)
+		require.NotEqual(t, uuid.Nil, results[4].ScheduledId)
 
 		userQuery := es.NewQuery[*aggregates.User]()
 		user, err := userQuery.Get(ctx, userId1)
@@ -116,6 +130,123 @@ func Test(t *testing.T) {
 		log.Printf("total: %+v", total)
 	})
 
+	t.Run("aggregate", func(t *testing.T) {
+		cli := tester.Client()
+
+		ctx := context.Background()
+		unit, errU := cli.Unit(ctx)
+		require.NoError(t, errU)
+
+		ctx = es.SetUnit(ctx, unit)
+
+		userQuery := es.NewQuery[*aggregates.User]()
+		rows, err := userQuery.Aggregate(ctx, es.Filter{}, []es.GroupBy{{Column: "type"}}, []es.Measure{es.Count("total")})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, "standard", rows[0].String("type"))
+		require.Equal(t, int64(1), rows[0].Int64("total"))
+	})
+
+	t.Run("select", func(t *testing.T) {
+		cli := tester.Client()
+
+		ctx := context.Background()
+		unit, errU := cli.Unit(ctx)
+		require.NoError(t, errU)
+
+		ctx = es.SetUnit(ctx, unit)
+
+		userQuery := es.NewQuery[*aggregates.User]()
+		users, err := userQuery.Find(ctx, es.Filter{Select: []string{"id", "namespace", "username"}})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		require.Equal(t, "chris.kolenko", users[0].Username)
+		require.Empty(t, users[0].Email)
+
+		type userSummary struct {
+			Id       uuid.UUID
+			Username string
+		}
+		summaries, err := es.FindAs[userSummary](ctx, userQuery, es.Filter{})
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+		require.Equal(t, "chris.kolenko", summaries[0].Username)
+	})
+
+	t.Run("gateway", func(t *testing.T) {
+		srv := httptest.NewServer(es.NewCommandGateway(tester.Client()))
+		defer srv.Close()
+
+		post := func(name string, body string) *http.Response {
+			resp, err := http.Post(srv.URL+"/commands/"+name, "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp
+		}
+
+		resp := post("addemail", `{"aggregate_id":"05de3d57-9c15-484c-aa9b-acf1002daa7c","email":"chris@other.gg"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotEmpty(t, resp.Header.Get("ETag"))
+
+		resp = post("addemail", `{"email":"chris@other.gg"}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = post("missing", `{}`)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		small := httptest.NewServer(es.NewCommandGateway(tester.Client(), es.WithMaxBodyBytes(16)))
+		defer small.Close()
+
+		resp, err := http.Post(small.URL+"/commands/addemail", "application/json", strings.NewReader(`{"aggregate_id":"05de3d57-9c15-484c-aa9b-acf1002daa7c"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		tenant := httptest.NewServer(es.NewCommandGateway(tester.Client(),
+			es.WithNamespaceExtractor(func(r *http.Request) (string, error) {
+				if r.Header.Get("X-Tenant") == "" {
+					return "", errors.New("missing tenant")
+				}
+				return r.Header.Get("X-Tenant"), nil
+			}),
+			es.WithActorExtractor(func(r *http.Request) (*es.Actor, error) {
+				if r.Header.Get("Authorization") == "" {
+					return nil, errors.New("missing token")
+				}
+				return &es.Actor{Id: uuid.New(), Type: "user"}, nil
+			}),
+		))
+		defer tenant.Close()
+
+		postAs := func(tenantId string, token string, name string, body string) *http.Response {
+			req, err := http.NewRequest(http.MethodPost, tenant.URL+"/commands/"+name, strings.NewReader(body))
+			require.NoError(t, err)
+			if tenantId != "" {
+				req.Header.Set("X-Tenant", tenantId)
+			}
+			if token != "" {
+				req.Header.Set("Authorization", token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp
+		}
+
+		body := `{"aggregate_id":"` + uuid.NewString() + `","username":"tenant","password":"12345678","namespace":"other"}`
+		resp = postAs("default", "token", "createuser", body)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = postAs("", "token", "createuser", body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = postAs("other", "", "createuser", body)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = postAs("other", "token", "createuser", body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
 	t.Run("run-saga", func(t *testing.T) {
 		cli := tester.Client()
 
@@ -151,4 +282,472 @@ func Test(t *testing.T) {
 		errD := unit.Handle(ctx, es.ExternalGroup, events...)
 		require.NoError(t, errD)
 	})
+
+	t.Run("ops", func(t *testing.T) {
+		ctx := context.Background()
+		op := ops.NewOperator(tester.Client())
+
+		userId := uuid.MustParse("05de3d57-9c15-484c-aa9b-acf1002daa7c")
+		stream, err := op.LoadAggregate(ctx, "StandardUser", "default", userId)
+		require.NoError(t, err)
+		require.NotEmpty(t, stream.Events)
+
+		total, err := op.RebuildProjection(ctx, "User")
+		require.NoError(t, err)
+		require.NotZero(t, total)
+
+		unit, err := tester.Client().Unit(ctx)
+		require.NoError(t, err)
+		user, err := es.NewQuery[*aggregates.User]().Get(es.SetUnit(ctx, unit), userId)
+		require.NoError(t, err)
+		require.Equal(t, "chris.kolenko", user.Username)
+
+		// projected aggregates are loaded from their events again.
+		rows, err := unit.Count(ctx, "StandardUser", "default", es.Filter{})
+		require.NoError(t, err)
+		require.NotZero(t, rows)
+		total, err = op.RebuildProjection(ctx, "StandardUser")
+		require.NoError(t, err)
+		require.NotZero(t, total)
+		rebuilt, err := unit.Count(ctx, "StandardUser", "default", es.Filter{})
+		require.NoError(t, err)
+		require.Equal(t, rows, rebuilt)
+
+		_, err = op.RebuildProjection(ctx, "Onboarding")
+		require.ErrorIs(t, err, ops.ErrNotProjection)
+
+		var dump bytes.Buffer
+		require.NoError(t, op.DumpNamespace(ctx, "default", &dump))
+		require.NoError(t, op.RestoreNamespace(ctx, "restored", &dump))
+
+		restored, err := op.LoadAggregate(ctx, "StandardUser", "restored", userId)
+		require.NoError(t, err)
+		require.Len(t, restored.Events, len(stream.Events))
+
+		// restored commands don't take over the ids they were dumped with.
+		dumped, err := op.ListCommands(ctx, "default")
+		require.NoError(t, err)
+		restoredCmds, err := op.ListCommands(ctx, "restored")
+		require.NoError(t, err)
+		require.Len(t, restoredCmds, len(dumped))
+		for _, cmd := range dumped {
+			for _, r := range restoredCmds {
+				require.NotEqual(t, cmd.Id, r.Id)
+			}
+		}
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		srv := httptest.NewServer(admin.NewHandler(tester.Client()))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Get(srv.URL + "/api/namespaces")
+		require.NoError(t, err)
+		var namespaces []string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&namespaces))
+		resp.Body.Close()
+		require.Contains(t, namespaces, "default")
+
+		resp, err = http.Post(srv.URL+"/api/aggregates/StandardUser/default/05de3d57-9c15-484c-aa9b-acf1002daa7c/replay", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		writer := httptest.NewServer(admin.NewHandler(tester.Client(), admin.WithWriteActions()))
+		defer writer.Close()
+
+		resp, err = http.Get(writer.URL + "/api/config")
+		require.NoError(t, err)
+		var cfg struct {
+			Projections []string `json:"projections"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&cfg))
+		resp.Body.Close()
+		require.Equal(t, []string{"User"}, cfg.Projections)
+
+		resp, err = http.Post(writer.URL+"/api/projections/StandardUser/rebuild", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, err = http.Post(writer.URL+"/api/projections/User/rebuild", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("dead-letters", func(t *testing.T) {
+		ctx := context.Background()
+		op := ops.NewOperator(tester.Client())
+
+		unit, err := tester.Client().Unit(ctx)
+		require.NoError(t, err)
+
+		now := time.Now()
+		persisted := &es.PersistedCommand{
+			Id:        uuid.New(),
+			Namespace: "default",
+			Command: &commands.AddEmail{
+				BaseCommand: es.BaseCommand{AggregateId: uuid.New()},
+				Email:       "nobody@context.gg",
+			},
+			CommandType:    "AddEmail",
+			ExecuteAfter:   now,
+			CreatedAt:      now,
+			Attempts:       5,
+			LastError:      "failed",
+			DeadLetteredAt: &now,
+		}
+		require.NoError(t, unit.Data().SavePersistedCommand(ctx, persisted))
+
+		deadLetters, err := op.ListDeadLetters(ctx, "default")
+		require.NoError(t, err)
+		require.Len(t, deadLetters, 1)
+		require.Equal(t, 5, deadLetters[0].Attempts)
+		require.Equal(t, "failed", deadLetters[0].LastError)
+
+		require.NoError(t, op.Redrive(ctx, "default", persisted.Id))
+
+		deadLetters, err = op.ListDeadLetters(ctx, "default")
+		require.NoError(t, err)
+		require.Empty(t, deadLetters)
+	})
+
+	t.Run("scheduler", func(t *testing.T) {
+		ctx := context.Background()
+		scheduler := tester.Client().Scheduler()
+
+		cmd := &commands.AddEmail{
+			BaseCommand: es.BaseCommand{AggregateId: uuid.New()},
+			Email:       "recurring@context.gg",
+		}
+		id, err := scheduler.ScheduleRecurring(ctx, cmd, "@every 1h")
+		require.NoError(t, err)
+
+		_, err = scheduler.ScheduleRecurring(ctx, cmd, "not a schedule")
+		require.Error(t, err)
+
+		// the 31st of February never comes around.
+		_, err = scheduler.ScheduleRecurring(ctx, cmd, "0 0 31 2 *")
+		require.Error(t, err)
+
+		byId := es.Filter{Where: es.WhereClause{Column: "id", Op: es.OpEqual, Args: id}}
+		scheduled, err := scheduler.List(ctx, byId)
+		require.NoError(t, err)
+		require.Len(t, scheduled, 1)
+		require.Equal(t, id, scheduled[0].Id)
+		require.Equal(t, "@every 1h", scheduled[0].Recurrence)
+
+		// a dead lettered command starts over when it's rescheduled.
+		unit, err := tester.Client().Unit(ctx)
+		require.NoError(t, err)
+		deadLettered := time.Now()
+		scheduled[0].Attempts = 5
+		scheduled[0].LastError = "failed"
+		scheduled[0].NextAttemptAt = &deadLettered
+		scheduled[0].DeadLetteredAt = &deadLettered
+		require.NoError(t, unit.Data().SavePersistedCommand(ctx, scheduled[0]))
+
+		executeAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		require.NoError(t, scheduler.Reschedule(ctx, id, executeAfter))
+
+		scheduled, err = scheduler.List(ctx, byId)
+		require.NoError(t, err)
+		require.Len(t, scheduled, 1)
+		require.True(t, executeAfter.Equal(scheduled[0].ExecuteAfter))
+		require.Zero(t, scheduled[0].Attempts)
+		require.Empty(t, scheduled[0].LastError)
+		require.Nil(t, scheduled[0].NextAttemptAt)
+		require.Nil(t, scheduled[0].DeadLetteredAt)
+
+		require.NoError(t, scheduler.Cancel(ctx, id))
+		require.ErrorIs(t, scheduler.Cancel(ctx, id), es.ErrNotFound)
+
+		scheduled, err = scheduler.List(ctx, byId)
+		require.NoError(t, err)
+		require.Empty(t, scheduled)
+
+		// a redelivered event schedules the same command at the same time once.
+		evtCtx := es.SetEvent(es.SetUnit(ctx, unit), &es.Event{
+			Service:       "users",
+			Namespace:     "default",
+			AggregateType: "User",
+			AggregateId:   cmd.AggregateId,
+			Version:       1,
+			Type:          "UserCreated",
+		})
+
+		first := time.Now().Add(time.Hour).Truncate(time.Second)
+		results, err := unit.Dispatch(evtCtx, es.NewScheduledCommand(cmd, first))
+		require.NoError(t, err)
+		redelivered, err := unit.Dispatch(evtCtx, es.NewScheduledCommand(cmd, first))
+		require.NoError(t, err)
+		require.Equal(t, results[0].ScheduledId, redelivered[0].ScheduledId)
+
+		byId = es.Filter{Where: es.WhereClause{Column: "id", Op: es.OpEqual, Args: results[0].ScheduledId}}
+		scheduled, err = scheduler.List(ctx, byId)
+		require.NoError(t, err)
+		require.Len(t, scheduled, 1)
+		require.True(t, first.Equal(scheduled[0].ExecuteAfter))
+
+		// the same command at another time is a reminder of its own.
+		later, err := unit.Dispatch(evtCtx, es.NewScheduledCommand(cmd, first.Add(7*24*time.Hour)))
+		require.NoError(t, err)
+		require.NotEqual(t, results[0].ScheduledId, later[0].ScheduledId)
+
+		require.NoError(t, scheduler.Cancel(ctx, results[0].ScheduledId))
+		require.NoError(t, scheduler.Cancel(ctx, later[0].ScheduledId))
+	})
+
+	t.Run("lease", func(t *testing.T) {
+		ctx := context.Background()
+
+		unit, err := tester.Client().Unit(ctx)
+		require.NoError(t, err)
+
+		now := time.Now()
+		expires := now.Add(time.Hour)
+		persisted := &es.PersistedCommand{
+			Id:        uuid.New(),
+			Namespace: "default",
+			Command: &commands.AddEmail{
+				BaseCommand: es.BaseCommand{AggregateId: uuid.New()},
+				Email:       "leased@context.gg",
+			},
+			CommandType:    "AddEmail",
+			ExecuteAfter:   now.Add(-time.Minute),
+			CreatedAt:      now,
+			LeasedBy:       "other",
+			LeaseExpiresAt: &expires,
+		}
+		require.NoError(t, unit.Data().SavePersistedCommand(ctx, persisted))
+
+		leasedIds := func(owner string) []uuid.UUID {
+			leased, err := unit.Data().LeasePersistedCommands(ctx, owner, now, time.Minute, 10)
+			require.NoError(t, err)
+
+			var ids []uuid.UUID
+			for _, cmd := range leased {
+				require.Equal(t, owner, cmd.LeasedBy)
+				ids = append(ids, cmd.Id)
+			}
+			return ids
+		}
+		require.NotContains(t, leasedIds("a"), persisted.Id)
+
+		// the other scheduler crashed, its lease can be reclaimed.
+		expired := now.Add(-time.Second)
+		persisted.LeaseExpiresAt = &expired
+		require.NoError(t, unit.Data().SavePersistedCommand(ctx, persisted))
+
+		require.Contains(t, leasedIds("a"), persisted.Id)
+		require.NotContains(t, leasedIds("b"), persisted.Id)
+
+		// only the scheduler holding the lease can finish the command.
+		require.ErrorIs(t, unit.Data().SaveLeasedCommand(ctx, "b", persisted), es.ErrLeaseLost)
+		require.ErrorIs(t, unit.Data().DeleteLeasedCommand(ctx, "b", persisted), es.ErrLeaseLost)
+		require.NoError(t, unit.Data().DeleteLeasedCommand(ctx, "a", persisted))
+		require.ErrorIs(t, unit.Data().DeleteLeasedCommand(ctx, "a", persisted), es.ErrLeaseLost)
+	})
+
+	t.Run("process", func(t *testing.T) {
+		cli := tester.Client()
+
+		ctx := context.Background()
+		unit, errU := cli.Unit(ctx)
+		require.NoError(t, errU)
+
+		ctx = es.SetUnit(ctx, unit)
+
+		userId := uuid.MustParse("05de3d57-9c15-484c-aa9b-acf1002daa7c")
+		events, err := unit.FindEvents(ctx, es.Filter{
+			Where: []es.WhereClause{
+				{Column: "aggregate_id", Op: es.OpEqual, Args: userId},
+				{Column: "namespace", Op: es.OpEqual, Args: "default"},
+				{Column: "type", Op: es.OpIn, Args: []string{"UserCreated", "EmailAdded"}},
+			},
+			Order: []es.Order{{Expression: "version"}},
+		})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(events), 2)
+		require.Equal(t, "UserCreated", events[0].Type)
+
+		require.NoError(t, unit.Handle(ctx, es.ExternalGroup, events[0]))
+
+		var process processes.Onboarding
+		require.NoError(t, unit.Get(ctx, "Onboarding", "default", userId, &process))
+		require.Equal(t, "chris.kolenko", process.Username)
+		require.False(t, process.IsCompleted())
+		require.Contains(t, process.Timeouts, "reminder")
+		reminderId := process.Timeouts["reminder"]
+
+		_, err = unit.Dispatch(ctx, &es.DeliverProcessTimeout{
+			BaseCommand: es.BaseCommand{AggregateId: userId},
+			Process:     "Onboarding",
+			Name:        "reminder",
+		})
+		require.NoError(t, err)
+
+		process = processes.Onboarding{}
+		require.NoError(t, unit.Get(ctx, "Onboarding", "default", userId, &process))
+		require.True(t, process.Reminded)
+		require.NotContains(t, process.Timeouts, "reminder")
+		require.NoError(t, cli.Scheduler().Cancel(ctx, reminderId))
+
+		// a new reminder is cancelled when the process completes.
+		require.NoError(t, unit.Handle(ctx, es.ExternalGroup, events[0]))
+		process = processes.Onboarding{}
+		require.NoError(t, unit.Get(ctx, "Onboarding", "default", userId, &process))
+		reminderId = process.Timeouts["reminder"]
+
+		require.NoError(t, unit.Handle(ctx, es.ExternalGroup, events[1]))
+
+		process = processes.Onboarding{}
+		require.NoError(t, unit.Get(ctx, "Onboarding", "default", userId, &process))
+		require.Equal(t, "chris@context.gg", process.Email)
+		require.True(t, process.IsCompleted())
+		require.Empty(t, process.Timeouts)
+		require.ErrorIs(t, cli.Scheduler().Cancel(ctx, reminderId), es.ErrNotFound)
+
+		// completed processes ignore further events.
+		require.NoError(t, unit.Handle(ctx, es.ExternalGroup, events[0]))
+		completed := processes.Onboarding{}
+		require.NoError(t, unit.Get(ctx, "Onboarding", "default", userId, &completed))
+		require.Equal(t, process, completed)
+
+		// a save from a stale copy loses to the one before it.
+		stale := process
+		stale.Version--
+		require.ErrorIs(t, unit.Data().SaveEntity(ctx, "Onboarding", &stale), es.ErrConcurrency)
+		require.Equal(t, process.Version-1, stale.Version)
+
+		fresh := process
+		fresh.Id = uuid.New()
+		fresh.Version = 0
+		require.NoError(t, unit.Data().SaveEntity(ctx, "Onboarding", &fresh))
+		require.Equal(t, 1, fresh.Version)
+		fresh.Version = 0
+		require.ErrorIs(t, unit.Data().SaveEntity(ctx, "Onboarding", &fresh), es.ErrConcurrency)
+	})
+
+	t.Run("event-dead-letters", func(t *testing.T) {
+		ctx := context.Background()
+		op := ops.NewOperator(tester.Client())
+
+		unit, err := tester.Client().Unit(ctx)
+		require.NoError(t, err)
+
+		events, err := unit.FindEvents(ctx, es.Filter{
+			Where: []es.WhereClause{
+				{Column: "aggregate_id", Op: es.OpEqual, Args: "05de3d57-9c15-484c-aa9b-acf1002daa7c"},
+				{Column: "type", Op: es.OpEqual, Args: "UserCreated"},
+			},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+
+		payload, err := es.MarshalEvent(ctx, events[0])
+		require.NoError(t, err)
+
+		deadLetter := &es.DeadLetter{
+			Id:        uuid.New(),
+			Group:     es.ExternalGroup,
+			Payload:   payload,
+			Error:     "failed",
+			Attempts:  3,
+			CreatedAt: time.Now(),
+		}
+		require.NoError(t, unit.Data().SaveDeadLetter(ctx, deadLetter))
+
+		deadLetters, err := op.ListEventDeadLetters(ctx, es.ExternalGroup)
+		require.NoError(t, err)
+		require.Len(t, deadLetters, 1)
+		require.Equal(t, 3, deadLetters[0].Attempts)
+		require.Equal(t, payload, deadLetters[0].Payload)
+
+		require.NoError(t, op.RedriveEvent(ctx, deadLetter.Id))
+		require.ErrorIs(t, op.RedriveEvent(ctx, deadLetter.Id), es.ErrNotFound)
+
+		deadLetters, err = op.ListEventDeadLetters(ctx, "")
+		require.NoError(t, err)
+		require.Empty(t, deadLetters)
+	})
+
+	t.Run("deduplicate", func(t *testing.T) {
+		cli := tester.Client()
+
+		ctx := context.Background()
+		unit, errU := cli.Unit(ctx)
+		require.NoError(t, errU)
+
+		ctx = es.SetUnit(ctx, unit)
+		ctx = helpers.SetSkipSaga(ctx)
+
+		userId := uuid.New()
+		_, err := unit.Dispatch(ctx, &commands.CreateUser{
+			BaseCommand: es.BaseCommand{AggregateId: userId},
+			Username:    "dedupe",
+			Password:    "12345678",
+		})
+		require.NoError(t, err)
+
+		events, err := unit.FindEvents(ctx, es.Filter{
+			Where: []es.WhereClause{
+				{Column: "aggregate_id", Op: es.OpEqual, Args: userId},
+				{Column: "type", Op: es.OpEqual, Args: "UserCreated"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+
+		// saving an event at a version that's taken is a conflict.
+		require.ErrorIs(t, unit.Data().SaveEvents(ctx, events), es.ErrConcurrency)
+
+		reminder := func() uuid.UUID {
+			var process processes.Onboarding
+			require.NoError(t, unit.Get(ctx, "Onboarding", "default", userId, &process))
+			return process.Timeouts["reminder"]
+		}
+
+		dedupe := es.SetDeduplicate(ctx)
+		require.NoError(t, unit.Handle(dedupe, es.ExternalGroup, events[0]))
+		first := reminder()
+		require.NotEqual(t, uuid.Nil, first)
+
+		// a redelivery is skipped.
+		require.NoError(t, unit.Handle(dedupe, es.ExternalGroup, events[0]))
+		require.Equal(t, first, reminder())
+
+		// other groups still see it.
+		processed, err := unit.Data().MarkEventProcessed(ctx, "other", events[0])
+		require.NoError(t, err)
+		require.True(t, processed)
+
+		// events without a version can't be told apart, so they are never marked.
+		unversioned := *events[0]
+		unversioned.Version = 0
+		require.NoError(t, unit.Handle(dedupe, "unversioned", &unversioned))
+		processed, err = unit.Data().MarkEventProcessed(ctx, "unversioned", &unversioned)
+		require.NoError(t, err)
+		require.True(t, processed)
+
+		// once purged the events are forgotten.
+		processed, err = unit.Data().MarkEventProcessed(ctx, es.ExternalGroup, events[0])
+		require.NoError(t, err)
+		require.False(t, processed)
+
+		op := ops.NewOperator(cli)
+		purged, err := op.PurgeProcessedEvents(context.Background(), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, purged, 3)
+
+		processed, err = unit.Data().MarkEventProcessed(ctx, es.ExternalGroup, events[0])
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
 }